@@ -0,0 +1,357 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// generatedHeader marks the files written by this command. Such files
+// are not parsed so that the previous output doesn't get in the way.
+const generatedHeader = "// Code generated by stev-docs-gen; DO NOT EDIT."
+
+type generatorOptions struct {
+	// The names of the structs to process. If it's empty, all the structs
+	// with a field which has the tag are processed.
+	TypeNames []string
+
+	TagKey string
+}
+
+// fieldDocs holds the docs of a field as extracted from the source.
+type fieldDocs struct {
+	Name        string
+	Description string
+	Deprecated  string
+	Values      []enumValue
+}
+
+type enumValue struct {
+	Value     string
+	ShortDesc string
+}
+
+type structDocs struct {
+	Name   string
+	Fields []fieldDocs
+}
+
+// generate parses the package in dir and returns its name along with
+// the source of the generated file.
+func generate(dir string, opts generatorOptions) (pkgName string, src []byte, err error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return "", nil, err
+		}
+		if isGenerated(f) {
+			continue
+		}
+		files = append(files, f)
+	}
+
+	pkg := collectPackage(files)
+	structs, err := pkg.selectStructs(opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s\n\npackage %s\n\n", generatedHeader, bp.Name)
+	fmt.Fprintf(&buf, "import \"github.com/rez-go/stev\"\n")
+	for _, sd := range structs {
+		writeDescriptorMethod(&buf, sd)
+	}
+	src, err = format.Source(buf.Bytes())
+	if err != nil {
+		return "", nil, fmt.Errorf("formatting the output: %w", err)
+	}
+	return bp.Name, src, nil
+}
+
+func isGenerated(f *ast.File) bool {
+	for _, cg := range f.Comments {
+		if cg.Pos() > f.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if c.Text == generatedHeader {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parsedPackage holds the declarations of a package relevant to the
+// generation.
+type parsedPackage struct {
+	structNames []string
+	structs     map[string]*ast.StructType
+
+	// Names of all the types declared in the package.
+	types map[string]bool
+
+	// Type names to the names of their methods.
+	methods map[string]map[string]bool
+
+	// Type names to the string constants of those types.
+	enums map[string][]enumValue
+}
+
+func collectPackage(files []*ast.File) *parsedPackage {
+	pkg := &parsedPackage{
+		structs: map[string]*ast.StructType{},
+		types:   map[string]bool{},
+		methods: map[string]map[string]bool{},
+		enums:   map[string][]enumValue{},
+	}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if decl.Recv == nil || len(decl.Recv.List) == 0 {
+					continue
+				}
+				recvName := typeIdentName(decl.Recv.List[0].Type)
+				if pkg.methods[recvName] == nil {
+					pkg.methods[recvName] = map[string]bool{}
+				}
+				pkg.methods[recvName][decl.Name.Name] = true
+			case *ast.GenDecl:
+				switch decl.Tok {
+				case token.TYPE:
+					for _, spec := range decl.Specs {
+						ts := spec.(*ast.TypeSpec)
+						pkg.types[ts.Name.Name] = true
+						if st, ok := ts.Type.(*ast.StructType); ok {
+							pkg.structNames = append(pkg.structNames, ts.Name.Name)
+							pkg.structs[ts.Name.Name] = st
+						}
+					}
+				case token.CONST:
+					pkg.collectEnums(decl)
+				}
+			}
+		}
+	}
+	return pkg
+}
+
+// collectEnums collects the typed constants which have string literal
+// values.
+func (pkg *parsedPackage) collectEnums(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		vs := spec.(*ast.ValueSpec)
+		typeName := typeIdentName(vs.Type)
+		if typeName == "" || len(vs.Values) != len(vs.Names) {
+			continue
+		}
+		doc := vs.Doc
+		if doc == nil {
+			doc = vs.Comment
+		}
+		if doc == nil && len(decl.Specs) == 1 {
+			doc = decl.Doc
+		}
+		for _, v := range vs.Values {
+			lit, ok := v.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+			value, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				continue
+			}
+			pkg.enums[typeName] = append(pkg.enums[typeName], enumValue{
+				Value:     value,
+				ShortDesc: firstLine(doc.Text()),
+			})
+		}
+	}
+}
+
+func (pkg *parsedPackage) selectStructs(opts generatorOptions) ([]structDocs, error) {
+	names := opts.TypeNames
+	explicit := len(names) > 0
+	if !explicit {
+		names = pkg.structNames
+	}
+
+	var structs []structDocs
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		st, ok := pkg.structs[name]
+		if !ok {
+			return nil, fmt.Errorf("struct type %s not found", name)
+		}
+		if !explicit && !hasTaggedField(st, opts.TagKey) {
+			continue
+		}
+		if pkg.methods[name]["FieldDocsDescriptor"] {
+			if !explicit {
+				continue
+			}
+			return nil, fmt.Errorf("type %s already has a FieldDocsDescriptor method", name)
+		}
+		sd := structDocs{Name: name}
+		for _, field := range st.Fields.List {
+			if isIgnoredField(field, opts.TagKey) {
+				continue
+			}
+			for _, fieldName := range fieldNames(field) {
+				fd := pkg.fieldDocs(fieldName, field)
+				if fd.Description != "" || fd.Deprecated != "" || len(fd.Values) > 0 {
+					sd.Fields = append(sd.Fields, fd)
+				}
+			}
+		}
+		if len(sd.Fields) > 0 {
+			structs = append(structs, sd)
+		}
+	}
+	if len(structs) == 0 {
+		return nil, errors.New("no documented struct fields found")
+	}
+	return structs, nil
+}
+
+func (pkg *parsedPackage) fieldDocs(fieldName string, field *ast.Field) fieldDocs {
+	fd := fieldDocs{Name: fieldName}
+	doc := field.Doc
+	if doc == nil {
+		doc = field.Comment
+	}
+	var paragraphs []string
+	for _, para := range strings.Split(doc.Text(), "\n\n") {
+		para = strings.Join(strings.Fields(para), " ")
+		if para == "" {
+			continue
+		}
+		if strings.HasPrefix(para, "Deprecated:") {
+			fd.Deprecated = strings.TrimSpace(strings.TrimPrefix(para, "Deprecated:"))
+			continue
+		}
+		paragraphs = append(paragraphs, para)
+	}
+	fd.Description = strings.Join(paragraphs, "\n")
+
+	if typeName := typeIdentName(field.Type); pkg.types[typeName] {
+		fd.Values = append(fd.Values, pkg.enums[typeName]...)
+	}
+	sort.SliceStable(fd.Values, func(i, j int) bool {
+		return fd.Values[i].Value < fd.Values[j].Value
+	})
+	return fd
+}
+
+func hasTaggedField(st *ast.StructType, tagKey string) bool {
+	for _, field := range st.Fields.List {
+		if _, ok := fieldTag(field).Lookup(tagKey); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isIgnoredField(field *ast.Field, tagKey string) bool {
+	name, _, _ := strings.Cut(fieldTag(field).Get(tagKey), ",")
+	return name == "-"
+}
+
+func fieldTag(field *ast.Field) reflect.StructTag {
+	if field.Tag == nil {
+		return ""
+	}
+	tag, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	return reflect.StructTag(tag)
+}
+
+// fieldNames returns the names of the exported fields declared by
+// field. The name of an embedded field is the name of its type.
+func fieldNames(field *ast.Field) []string {
+	var names []string
+	if len(field.Names) == 0 {
+		if name := typeIdentName(field.Type); name != "" {
+			names = append(names, name)
+		}
+	}
+	for _, ident := range field.Names {
+		names = append(names, ident.Name)
+	}
+	exported := names[:0]
+	for _, name := range names {
+		if token.IsExported(name) {
+			exported = append(exported, name)
+		}
+	}
+	return exported
+}
+
+// typeIdentName returns the name of the type expr refers to, through
+// a pointer, if it's declared in the same package.
+func typeIdentName(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.StarExpr:
+		return typeIdentName(expr.X)
+	case *ast.IndexExpr:
+		return typeIdentName(expr.X)
+	case *ast.IndexListExpr:
+		return typeIdentName(expr.X)
+	}
+	return ""
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(line)
+}
+
+func writeDescriptorMethod(buf *bytes.Buffer, sd structDocs) {
+	fmt.Fprintf(buf, "\n// FieldDocsDescriptor returns the docs of the fields of %s as\n", sd.Name)
+	fmt.Fprintf(buf, "// written in their doc comments.\n")
+	fmt.Fprintf(buf, "func (%s) FieldDocsDescriptor(fieldName string) *stev.FieldDocsDescriptor {\n", sd.Name)
+	fmt.Fprintf(buf, "switch fieldName {\n")
+	for _, fd := range sd.Fields {
+		fmt.Fprintf(buf, "case %q:\n", fd.Name)
+		fmt.Fprintf(buf, "return &stev.FieldDocsDescriptor{\n")
+		if fd.Description != "" {
+			fmt.Fprintf(buf, "Description: %s,\n", strconv.Quote(fd.Description))
+		}
+		if fd.Deprecated != "" {
+			fmt.Fprintf(buf, "Deprecated: %s,\n", strconv.Quote(fd.Deprecated))
+		}
+		if len(fd.Values) > 0 {
+			fmt.Fprintf(buf, "AvailableValues: map[string]stev.EnumValueDocs{\n")
+			for _, v := range fd.Values {
+				fmt.Fprintf(buf, "%s: {ShortDesc: %s},\n",
+					strconv.Quote(v.Value), strconv.Quote(v.ShortDesc))
+			}
+			fmt.Fprintf(buf, "},\n")
+		}
+		fmt.Fprintf(buf, "}\n")
+	}
+	fmt.Fprintf(buf, "}\nreturn nil\n}\n")
+}
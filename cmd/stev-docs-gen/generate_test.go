@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSource = `package config
+
+// Mode selects how the requests are handled.
+type Mode string
+
+const (
+	// Handle the requests as fast as possible.
+	ModeFast Mode = "fast"
+	ModeSlow Mode = "slow" // Handle the requests one by one.
+)
+
+type Config struct {
+	// The name of the service.
+	//
+	// It's used in the logs.
+	Name string ` + "`env:\",required\"`" + `
+
+	Mode Mode // The mode.
+
+	// Deprecated: use Mode.
+	Fast bool
+
+	// Not loaded.
+	Internal string ` + "`env:\"-\"`" + `
+
+	Undocumented string
+}
+
+type Untagged struct {
+	// Not a config.
+	Name string
+}
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(testSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	pkgName, src, err := generate(dir, generatorOptions{TagKey: "env"})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if pkgName != "config" {
+		t.Errorf("Unexpected package name %q", pkgName)
+	}
+	expected := generatedHeader + `
+
+package config
+
+import "github.com/rez-go/stev"
+
+// FieldDocsDescriptor returns the docs of the fields of Config as
+// written in their doc comments.
+func (Config) FieldDocsDescriptor(fieldName string) *stev.FieldDocsDescriptor {
+	switch fieldName {
+	case "Name":
+		return &stev.FieldDocsDescriptor{
+			Description: "The name of the service.\nIt's used in the logs.",
+		}
+	case "Mode":
+		return &stev.FieldDocsDescriptor{
+			Description: "The mode.",
+			AvailableValues: map[string]stev.EnumValueDocs{
+				"fast": {ShortDesc: "Handle the requests as fast as possible."},
+				"slow": {ShortDesc: "Handle the requests one by one."},
+			},
+		}
+	case "Fast":
+		return &stev.FieldDocsDescriptor{
+			Deprecated: "use Mode.",
+		}
+	}
+	return nil
+}
+`
+	if string(src) != expected {
+		t.Errorf("Unexpected output:\n%s", src)
+	}
+
+	// The previous output must not prevent the generation
+	if err := os.WriteFile(filepath.Join(dir, "config_stevdocs.go"), src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := generate(dir, generatorOptions{TagKey: "env"}); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+
+	_, _, err = generate(dir, generatorOptions{TagKey: "env", TypeNames: []string{"Missing"}})
+	if err == nil || !strings.Contains(err.Error(), "struct type Missing not found") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Command stev-docs-gen generates the FieldDocsDescriptor methods of
+// configuration structs from the doc comments of their fields.
+//
+// It's meant to be invoked by go generate:
+//
+//	//go:generate go run github.com/rez-go/stev/cmd/stev-docs-gen
+//
+// By default, it processes every struct of the package in the current
+// directory which has a field with the env tag. Use -type to select
+// the structs explicitly.
+//
+// The description of a field is the text of its doc comment, or of its
+// line comment if it has none. A paragraph starting with "Deprecated:"
+// becomes the deprecation note. If the type of a field is declared in
+// the package and there are constants of that type with string values,
+// the constants become the available values of the field; their
+// comments become the short descriptions of the values.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "",
+		"comma-separated list of the struct type names; all the structs with the tag if empty")
+	output := flag.String("output", "",
+		"output file name; default is <package>_stevdocs.go in the package directory")
+	tagKey := flag.String("tag", "env", "the key of the struct field tag")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: stev-docs-gen [flags] [directory]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	opts := generatorOptions{TagKey: *tagKey}
+	if *typeNames != "" {
+		opts.TypeNames = strings.Split(*typeNames, ",")
+	}
+
+	pkgName, src, err := generate(dir, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "stev-docs-gen: %v\n", err)
+		os.Exit(1)
+	}
+
+	outPath := *output
+	if outPath == "" {
+		outPath = filepath.Join(dir, pkgName+"_stevdocs.go")
+	}
+	if err := os.WriteFile(outPath, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "stev-docs-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,60 @@
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func init() {
+	RegisterFormat("docker-compose", FormatFunc(writeDockerCompose))
+}
+
+// WriteDockerCompose writes an environment mapping for a docker-compose
+// service. Each key is interpolated from the environment of the shell
+// running docker-compose:
+//
+//   - required keys use ${KEY:?required} so that docker-compose fails if
+//     they are not set,
+//   - keys with default values use ${KEY:-default},
+//   - other keys are passed through only when they are set.
+func WriteDockerCompose(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "docker-compose", opts)
+}
+
+func writeDockerCompose(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	fmt.Fprintln(writer, "environment:")
+	for _, fd := range docs.ConcreteFields() {
+		writeYAMLComment(writer, "  ", fd.Description)
+		key := yamlString(fd.LookupKey)
+		switch {
+		case docs.IsRequired(fd) && fd.Value == "":
+			fmt.Fprintf(writer, "  %s: %s\n", key,
+				yamlString("${"+fd.LookupKey+":?required}"))
+		case fd.Value != "" && !strings.Contains(fd.Value, "}"):
+			fmt.Fprintf(writer, "  %s: %s\n", key,
+				yamlString("${"+fd.LookupKey+":-"+composeEscape(fd.Value)+"}"))
+		case fd.Value != "":
+			// The default can't be put in the interpolation
+			fmt.Fprintf(writer, "  %s: %s\n", key,
+				yamlString(composeEscape(fd.Value)))
+		default:
+			fmt.Fprintf(writer, "  %s:\n", key)
+		}
+	}
+	return nil
+}
+
+// composeEscape escapes the dollar signs which would be interpreted as
+// interpolations by docker-compose.
+func composeEscape(s string) string {
+	return strings.ReplaceAll(s, "$", "$$")
+}
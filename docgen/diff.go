@@ -0,0 +1,308 @@
+package docgen
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rez-go/stev"
+)
+
+// DiffReport holds the differences between an env file and the keys
+// the configuration reads. See Diff.
+type DiffReport struct {
+	// Keys which are read but not in the file.
+	Missing []DiffEntry `json:"missing"`
+	// Keys which are in the file but not read.
+	Obsolete []DiffEntry `json:"obsolete"`
+	// Required keys which are in the file but without a value.
+	EmptyRequired []DiffEntry `json:"empty_required"`
+	// Keys whose values can't be loaded into their fields.
+	Invalid []DiffEntry `json:"invalid"`
+	// Keys which match a map-entry placeholder but whose entries are
+	// not in the skeleton. The loader only reads the entries which are
+	// present in the target; such keys are ignored unless the
+	// application creates their entries.
+	Unregistered []DiffEntry `json:"unregistered"`
+}
+
+// DiffEntry is a key reported by Diff.
+type DiffEntry struct {
+	Key string `json:"key"`
+	// The path to the field, if the key is read.
+	Path     string `json:"path,omitempty"`
+	Required bool   `json:"required,omitempty"`
+	// Why the value is invalid.
+	Error string `json:"error,omitempty"`
+}
+
+// HasDrift returns true if the file needs to be updated. The optional
+// keys missing from the file are not considered drift as their
+// fields keep the default values. The unregistered keys are, as they
+// can't be verified; the skeleton should hold the entries created by
+// the application.
+func (r *DiffReport) HasDrift() bool {
+	if len(r.Obsolete) > 0 || len(r.EmptyRequired) > 0 || len(r.Invalid) > 0 ||
+		len(r.Unregistered) > 0 {
+		return true
+	}
+	for _, e := range r.Missing {
+		if e.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// IsEmpty returns true if nothing has been reported.
+func (r *DiffReport) IsEmpty() bool {
+	return len(r.Missing) == 0 && len(r.Obsolete) == 0 &&
+		len(r.EmptyRequired) == 0 && len(r.Invalid) == 0 &&
+		len(r.Unregistered) == 0
+}
+
+// WriteText writes the report in a human-readable form, one key per
+// line, grouped by the kind of difference.
+func (r *DiffReport) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	if r.IsEmpty() {
+		fmt.Fprintln(ew, "No differences.")
+		return ew.err
+	}
+	writeGroup := func(title string, entries []DiffEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Fprintf(ew, "%s:\n", title)
+		for _, e := range entries {
+			line := "  " + e.Key
+			if e.Required {
+				line += " (required)"
+			}
+			if e.Error != "" {
+				line += ": " + e.Error
+			}
+			fmt.Fprintln(ew, line)
+		}
+	}
+	writeGroup("Missing keys", r.Missing)
+	writeGroup("Obsolete keys", r.Obsolete)
+	writeGroup("Required keys without value", r.EmptyRequired)
+	writeGroup("Invalid values", r.Invalid)
+	writeGroup("Keys of map entries not in the skeleton", r.Unregistered)
+	return ew.err
+}
+
+// WriteJSON writes the report as an indented JSON object.
+func (r *DiffReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// Diff compares the dotenv-style file read from existingEnv with the
+// keys of skeleton. See stev.ParseEnvFile for the format of the file.
+//
+// The keys of the map-entry placeholders are never reported missing.
+// A key in the file which matches one of them, but whose entry is not
+// in the skeleton, is reported as unregistered rather than obsolete:
+// it's only read if the application creates the entry. Its value is
+// still checked. The keys of the fields with the docs_hidden tag option are
+// only reported missing with ShowHidden. With a FieldPrefix, the keys
+// in the file without the prefix are ignored.
+//
+// A required key in a section which is only activated when any of its
+// keys is set, e.g., a pointer struct, is only reported if any key of
+// the section is in the file.
+func Diff(existingEnv io.Reader, skeleton interface{}, opts WriteOptions) (*DiffReport, error) {
+	entries, err := stev.ParseEnvFile(existingEnv)
+	if err != nil {
+		return nil, fmt.Errorf("docgen: %w", err)
+	}
+	// The hidden fields are still read; they are only excluded from
+	// the missing keys.
+	allOpts := opts
+	allOpts.ShowHidden = true
+	docs, err := LoadDocs(skeleton, allOpts)
+	if err != nil {
+		return nil, fmt.Errorf("docgen: %w", err)
+	}
+	checkValue := stev.CheckValue
+	if opts.Loader != nil {
+		checkValue = opts.Loader.CheckValue
+	}
+
+	report := &DiffReport{
+		Missing:       []DiffEntry{},
+		Obsolete:      []DiffEntry{},
+		EmptyRequired: []DiffEntry{},
+		Invalid:       []DiffEntry{},
+		Unregistered:  []DiffEntry{},
+	}
+
+	// Sections with any of their keys in the file.
+	usedSections := map[*Section]bool{}
+	for _, fd := range docs.ConcreteFields() {
+		if _, ok := entries[fd.LookupKey]; ok {
+			usedSections[docs.SectionOf(fd)] = true
+		}
+	}
+
+	known := map[string]bool{}
+	for _, fd := range docs.ConcreteFields() {
+		known[fd.LookupKey] = true
+		sec := docs.SectionOf(fd)
+		required := fd.Required && (!sec.IsOptional() || usedSections[sec])
+		val, ok := entries[fd.LookupKey]
+		entry := DiffEntry{Key: fd.LookupKey, Path: fd.Path, Required: required}
+		switch {
+		case !ok:
+			if fd.Hidden && !opts.ShowHidden {
+				continue
+			}
+			report.Missing = append(report.Missing, entry)
+		// An empty value is true for a bool field.
+		case val == "" && required && fd.Kind != stev.FieldKindBool:
+			report.EmptyRequired = append(report.EmptyRequired, entry)
+		default:
+			if err := checkFieldValue(checkValue, fd, val); err != nil {
+				entry.Error = err.Error()
+				report.Invalid = append(report.Invalid, entry)
+			}
+		}
+	}
+
+	type placeholderField struct {
+		pattern *regexp.Regexp
+		fd      stev.FieldDocs
+	}
+	var placeholders []placeholderField
+	for _, fd := range docs.Fields {
+		if fd.Placeholder {
+			placeholders = append(placeholders, placeholderField{
+				regexp.MustCompile(placeholderKeyPattern(fd.LookupKey)), fd})
+		}
+	}
+
+	keys := make([]string, 0, len(entries))
+	for k := range entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if known[k] || !strings.HasPrefix(k, opts.FieldPrefix) {
+			continue
+		}
+		matched := false
+		for _, p := range placeholders {
+			if !p.pattern.MatchString(k) {
+				continue
+			}
+			matched = true
+			report.Unregistered = append(report.Unregistered, DiffEntry{
+				Key: k, Path: p.fd.Path})
+			if err := checkFieldValue(checkValue, p.fd, entries[k]); err != nil {
+				report.Invalid = append(report.Invalid, DiffEntry{
+					Key: k, Path: p.fd.Path, Error: err.Error()})
+			}
+			break
+		}
+		if !matched {
+			report.Obsolete = append(report.Obsolete, DiffEntry{Key: k})
+		}
+	}
+	sort.SliceStable(report.Invalid, func(i, j int) bool {
+		return report.Invalid[i].Key < report.Invalid[j].Key
+	})
+
+	return report, nil
+}
+
+// checkFieldValue returns an error if val can't be loaded into the
+// field or if it's not one of the available values of the field.
+func checkFieldValue(
+	checkValue func(t reflect.Type, strVal string) error,
+	fd stev.FieldDocs,
+	val string,
+) error {
+	if fd.Type != nil {
+		if err := checkValue(fd.Type, val); err != nil {
+			return err
+		}
+	}
+	if len(fd.AvailableValues) > 0 && val != "" {
+		if _, ok := fd.AvailableValues[val]; !ok {
+			enumVals := make([]string, 0, len(fd.AvailableValues))
+			for k := range fd.AvailableValues {
+				enumVals = append(enumVals, k)
+			}
+			sort.Strings(enumVals)
+			return fmt.Errorf("unknown value %q (available: %s)",
+				val, strings.Join(enumVals, ", "))
+		}
+	}
+	return nil
+}
+
+// RunDiff is a command-style helper around Diff, e.g., for a
+// subcommand of the application which checks its env files in CI.
+// args are the arguments of the command, without the command name:
+//
+//	[-json] FILE
+//
+// The report is written through stdout and the usage and the errors
+// through stderr. It returns the exit status: 0 if there's no drift
+// (see DiffReport.HasDrift), 1 if there is, and 2 on error.
+func RunDiff(
+	args []string,
+	stdout, stderr io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) int {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	asJSON := flags.Bool("json", false, "write the report as JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: diff [-json] FILE")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	defer f.Close()
+	report, err := Diff(f, skeleton, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	if *asJSON {
+		err = report.WriteJSON(stdout)
+	} else {
+		err = report.WriteText(stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if report.HasDrift() {
+		return 1
+	}
+	return 0
+}
@@ -10,90 +10,230 @@ import (
 	"github.com/rez-go/stev"
 )
 
-type EnvTemplateWriteOptions struct {
-	FieldPrefix string
+// EnvTemplateWriteOptions is the former name of WriteOptions.
+type EnvTemplateWriteOptions = WriteOptions
 
-	// By default, the fields are sorted alphabetically by the keys. If it's
-	// prefered to keep their order as found in the structs, set this option
-	// to true.
-	OriginalOrdering bool
-
-	// If set to true, the path to each field will be printed in the output.
-	ShowPaths bool
+func init() {
+	RegisterFormat("env", FormatFunc(writeEnvTemplate))
 }
 
 // WriteEnvTemplate writes the template passed as `skeleton` through `writer`.
+//
+// The keys of each nested struct are grouped under a banner, with the
+// required keys first.
 func WriteEnvTemplate(
 	writer io.Writer,
 	skeleton interface{},
 	opts EnvTemplateWriteOptions,
 ) error {
-	fieldDocs, err := stev.Docs(opts.FieldPrefix, skeleton)
-	if err != nil {
-		panic(err)
+	return WriteDocs(writer, skeleton, "env", opts)
+}
+
+func writeEnvTemplate(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	for _, f := range envTemplateFields(docs) {
+		if f.first && f.section != nil {
+			writeEnvBanner(writer, f.section)
+		}
+		writeEnvField(writer, f.FieldDocs, opts)
 	}
+	return nil
+}
+
+// envBannerLine is the line which delimits the banner of a section.
+var envBannerLine = strings.Repeat("#", 72)
+
+// envTemplateField is a field in the order of the env template.
+type envTemplateField struct {
+	stev.FieldDocs
+	// The section under whose banner the field is. It's nil for the
+	// fields of the root section.
+	section *Section
+	// The field is the first of the section.
+	first bool
+}
 
-	if !opts.OriginalOrdering {
-		sort.Slice(fieldDocs, func(i, j int) bool {
-			return strings.Compare(fieldDocs[i].LookupKey, fieldDocs[j].LookupKey) < 0
+// envTemplateFields returns the fields in the order of the env
+// template. The fields of each section, including those of its
+// squashed sections, are followed by its nested sections. The fields
+// keep their order in the docs after the required ones.
+func envTemplateFields(docs *Docs) []envTemplateField {
+	order := map[string]int{}
+	for i, fd := range docs.Fields {
+		order[fd.Path] = i
+	}
+	var out []envTemplateField
+	var collect func(s *Section)
+	collect = func(s *Section) {
+		fields, subSections := envSectionContent(s)
+		sort.Slice(fields, func(i, j int) bool {
+			if fields[i].Required != fields[j].Required {
+				return fields[i].Required
+			}
+			return order[fields[i].Path] < order[fields[j].Path]
 		})
+		var section *Section
+		if s.Parent != nil {
+			section = s
+		}
+		for i, fd := range fields {
+			out = append(out, envTemplateField{fd, section, i == 0})
+		}
+		for _, sub := range subSections {
+			collect(sub)
+		}
 	}
+	collect(docs.Root)
+	return out
+}
 
-	for _, fd := range fieldDocs {
-		fmt.Fprintf(writer, "\n")
-		if fd.Description != "" {
-			descLines := strings.Split(wordwrap.WrapString(fd.Description, 72), "\n")
-			for _, l := range descLines {
-				fmt.Fprintln(writer, "#", l)
-			}
-			fmt.Fprintln(writer, "#")
+// envSectionContent returns the fields of s and the sections nested in
+// it. The squashed sections are merged into s as their keys don't have
+// a prefix of their own.
+func envSectionContent(s *Section) (fields []stev.FieldDocs, subSections []*Section) {
+	fields = append(fields, s.OrderedFields...)
+	for _, sub := range s.Subsections {
+		if !sub.Squashed {
+			subSections = append(subSections, sub)
+			continue
 		}
-		if fd.Required {
-			fmt.Fprintf(writer, "# required\n")
+		subFields, subSubSections := envSectionContent(sub)
+		fields = append(fields, subFields...)
+		subSections = append(subSections, subSubSections...)
+	}
+	return fields, subSections
+}
+
+func writeEnvBanner(writer io.Writer, s *Section) {
+	fmt.Fprintf(writer, "\n%s\n", envBannerLine)
+	fmt.Fprintf(writer, "# %s\n", s.Title())
+	if s.Description != "" {
+		fmt.Fprintln(writer, "#")
+		for _, l := range strings.Split(wordwrap.WrapString(s.Description, 72), "\n") {
+			fmt.Fprintln(writer, "#", l)
 		}
-		fmt.Fprintf(writer, "# type: %s\n", fd.DataType)
-		if len(fd.AvailableValues) > 0 {
-			fmt.Fprintf(writer, "#\n# Available values:\n")
-
-			keyLenMax := 0
-			enumVals := make([]string, len(fd.AvailableValues))
-			i := 0
-			for k := range fd.AvailableValues {
-				enumVals[i] = k
-				i++
-
-				if len(k) > keyLenMax {
-					keyLenMax = len(k)
-				}
-			}
-			sort.Strings(enumVals)
+	}
+	fmt.Fprintln(writer, "#")
+	fmt.Fprintf(writer, "# prefix: %s\n", s.Prefix)
+	if s.MapEntry != nil && s.MapEntry.Placeholder {
+		fmt.Fprintf(writer, "# key format: %s\n", s.MapEntry.KeyFormat)
+	}
+	if s.Optional {
+		fmt.Fprintln(writer, "# optional: only activated when any of its keys is set")
+	}
+	if s.Required {
+		fmt.Fprintln(writer, "# required: at least one of its keys must be set")
+	}
+	fmt.Fprintln(writer, envBannerLine)
+}
 
-			if keyLenMax > 14 {
-				keyLenMax = 14
-			}
+func writeEnvField(writer io.Writer, fd stev.FieldDocs, opts WriteOptions) {
+	writeEnvFieldQuoted(writer, fd, opts, dotenvQuote)
+}
+
+// writeEnvFieldQuoted writes the field like writeEnvField, with the
+// values quoted by quote, which must keep them on a single line.
+func writeEnvFieldQuoted(
+	writer io.Writer,
+	fd stev.FieldDocs,
+	opts WriteOptions,
+	quote func(string) string,
+) {
+	fmt.Fprintf(writer, "\n")
+	if fd.Description != "" {
+		descLines := strings.Split(wordwrap.WrapString(fd.Description, 72), "\n")
+		for _, l := range descLines {
+			fmt.Fprintln(writer, "#", l)
+		}
+		fmt.Fprintln(writer, "#")
+	}
+	if fd.Required {
+		fmt.Fprintf(writer, "# required\n")
+	}
+	fmt.Fprintf(writer, "# type: %s\n", fd.DataType)
+	if fd.TypeHint != "" && fd.TypeHint != fd.DataType {
+		fmt.Fprintf(writer, "# format: %s\n", fd.TypeHint)
+	}
+	if fd.Since != "" {
+		fmt.Fprintf(writer, "# since: %s\n", fd.Since)
+	}
+	if fd.Deprecated != "" {
+		fmt.Fprintf(writer, "# deprecated: %s\n", fd.Deprecated)
+	}
+	if fd.Example != "" {
+		fmt.Fprintf(writer, "# example: %s=%s\n", fd.LookupKey, quote(fd.Example))
+	}
+	if len(fd.AvailableValues) > 0 {
+		fmt.Fprintf(writer, "#\n# Available values:\n")
+
+		keyLenMax := 0
+		enumVals := make([]string, len(fd.AvailableValues))
+		i := 0
+		for k := range fd.AvailableValues {
+			enumVals[i] = k
+			i++
 
-			for _, enumVal := range enumVals {
-				docs := fd.AvailableValues[enumVal]
-				if docs.ShortDesc != "" {
-					fmt.Fprintf(writer,
-						"#   %-*s   %s\n",
-						keyLenMax, enumVal, docs.ShortDesc)
-				} else {
-					fmt.Fprintf(writer, "#   %s\n", enumVal)
-				}
+			if len(k) > keyLenMax {
+				keyLenMax = len(k)
 			}
 		}
-		if opts.ShowPaths {
-			fmt.Fprintf(writer, "# path: %s\n", fd.Path)
+		sort.Strings(enumVals)
+
+		if keyLenMax > 14 {
+			keyLenMax = 14
 		}
-		if fd.Value != "" {
-			fmt.Fprintf(writer, "# %s=%s\n", fd.LookupKey, fd.Value) //TODO: escape? quote?
-		} else if fd.Required {
-			fmt.Fprintf(writer, "%s=\n", fd.LookupKey)
-		} else {
-			fmt.Fprintf(writer, "# %s=\n", fd.LookupKey)
+
+		for _, enumVal := range enumVals {
+			docs := fd.AvailableValues[enumVal]
+			if docs.ShortDesc != "" {
+				fmt.Fprintf(writer,
+					"#   %-*s   %s\n",
+					keyLenMax, enumVal, docs.ShortDesc)
+			} else {
+				fmt.Fprintf(writer, "#   %s\n", enumVal)
+			}
 		}
 	}
+	if opts.ShowPaths {
+		fmt.Fprintf(writer, "# path: %s\n", fd.Path)
+	}
+	if fd.Value != "" {
+		fmt.Fprintf(writer, "# %s=%s\n", fd.LookupKey, quote(fd.Value))
+	} else if fd.Required && !fd.Placeholder {
+		fmt.Fprintf(writer, "%s=\n", fd.LookupKey)
+	} else {
+		fmt.Fprintf(writer, "# %s=\n", fd.LookupKey)
+	}
+}
 
-	return nil
+// dotenvQuote quotes s, if necessary, to be read back as it is by
+// dotenv parsers, e.g., stev.ParseEnvFile. The result is always on a
+// single line so that it could be commented out.
+func dotenvQuote(s string) string {
+	if s != "" && strings.TrimSpace(s) == s &&
+		!strings.ContainsAny(s, " \t\r\n\"'\\$`#") {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '$', '`':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
 }
@@ -0,0 +1,1096 @@
+package docgen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/rez-go/stev"
+	"github.com/rez-go/stev/docgen"
+)
+
+type testConfig struct {
+	Name    string `env:",required"`
+	Port    int32
+	Enabled bool
+}
+
+func (testConfig) FieldDescriptions() map[string]string {
+	return map[string]string{
+		"Name": "The name of the service.",
+	}
+}
+
+func TestWriteEnvTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteEnvTemplate(&buf, &testConfig{Port: 8080}, docgen.EnvTemplateWriteOptions{
+		FieldPrefix: "APP_",
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `
+# The name of the service.
+#
+# required
+# type: string
+APP_NAME=
+
+# type: bool
+# format: boolean (true/false/1/0; empty means true)
+# APP_ENABLED=
+
+# type: int32
+# format: integer -2147483648 to 2147483647
+# APP_PORT=8080
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteDocsUnknownFormat(t *testing.T) {
+	err := docgen.WriteDocs(io.Discard, &testConfig{}, "unknown", docgen.WriteOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestWriteDocsInvalidSkeleton(t *testing.T) {
+	err := docgen.WriteDocs(io.Discard, testConfig{}, "env", docgen.WriteOptions{})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+type failingWriter struct{}
+
+var errWriteFailed = errors.New("write failed")
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+func TestWriteDocsWriterError(t *testing.T) {
+	err := docgen.WriteDocs(failingWriter{}, &testConfig{}, "env", docgen.WriteOptions{})
+	if !errors.Is(err, errWriteFailed) {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestRegisterFormat(t *testing.T) {
+	docgen.RegisterFormat("test-keys", docgen.FormatFunc(
+		func(w io.Writer, docs *docgen.Docs, opts docgen.WriteOptions) error {
+			for _, fd := range docs.Fields {
+				io.WriteString(w, fd.LookupKey+"\n")
+			}
+			return nil
+		}))
+	var buf bytes.Buffer
+	err := docgen.WriteDocs(&buf, &testConfig{}, "test-keys", docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if buf.String() != "ENABLED\nNAME\nPORT\n" {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+type markdownInner struct {
+	Mode string
+}
+
+func (markdownInner) SelfDocsDescriptor() stev.SelfDocsDescriptor {
+	return stev.SelfDocsDescriptor{ShortDesc: "Inner settings"}
+}
+
+func (markdownInner) FieldDocsDescriptor(fieldName string) *stev.FieldDocsDescriptor {
+	if fieldName != "Mode" {
+		return nil
+	}
+	return &stev.FieldDocsDescriptor{
+		Description: "The mode.\nPipes | must be escaped.",
+		AvailableValues: map[string]stev.EnumValueDocs{
+			"fast": {ShortDesc: "Fast mode"},
+			"slow": {},
+		},
+	}
+}
+
+type markdownConfig struct {
+	Name  string `env:",required"`
+	Inner markdownInner
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteMarkdown(&buf, &markdownConfig{Name: "a|b"}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := "| Key | Type | Required | Default | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `NAME` | `string` | yes | `a\\|b` |  |\n" +
+		"\n" +
+		"## Inner settings\n" +
+		"\n" +
+		"Path: `.Inner`\n" +
+		"\n" +
+		"| Key | Type | Required | Default | Description |\n" +
+		"| --- | --- | --- | --- | --- |\n" +
+		"| `INNER_MODE` | `string` |  |  | The mode.<br>Pipes \\| must be escaped.<br>" +
+		"Available values:<br>- `fast`: Fast mode<br>- `slow` |\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestUpdateFileSection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	original := "# Service\n\n<!-- stev:begin -->\nstale\n<!-- stev:end -->\n\nFooter\n"
+	if err := os.WriteFile(path, []byte(original), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := docgen.CheckFileSection(path, "", &testConfig{}, "env", docgen.WriteOptions{})
+	if !errors.Is(err, docgen.ErrStaleSection) {
+		t.Errorf("Expected ErrStaleSection, got %v", err)
+	}
+
+	err = docgen.UpdateFileSection(path, "", &testConfig{}, "env", docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "# Service\n\n<!-- stev:begin -->\n```\n# The name of the service.\n") ||
+		!strings.HasSuffix(string(content), "# PORT=\n```\n<!-- stev:end -->\n\nFooter\n") {
+		t.Errorf("Unexpected content:\n%s", content)
+	}
+
+	err = docgen.CheckFileSection(path, "", &testConfig{}, "env", docgen.WriteOptions{})
+	if err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+}
+
+func TestUpdateFileSectionMissingMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	if err := os.WriteFile(path, []byte("<!-- stev:begin env -->\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := docgen.UpdateFileSection(path, "env", &testConfig{}, "env", docgen.WriteOptions{})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+type schemaModule struct {
+	Endpoint string `env:",required"`
+}
+
+type schemaConfig struct {
+	Name     string `env:",required"`
+	Port     uint16
+	Timeout  time.Duration
+	Optional *schemaModule
+	Modules  map[string]interface{} `env:"MOD,map"`
+}
+
+func TestWriteJSONSchema(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteJSONSchema(&buf, &schemaConfig{
+		Port:    8080,
+		Timeout: time.Second,
+		Modules: map[string]interface{}{"auth": &schemaModule{}},
+	}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+
+	var schema struct {
+		Properties        map[string]map[string]interface{}
+		PatternProperties map[string]map[string]interface{}
+		Required          []string
+	}
+	if err = json.Unmarshal(buf.Bytes(), &schema); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if strings.Join(schema.Required, ",") != "NAME" {
+		t.Errorf("Unexpected required keys %v", schema.Required)
+	}
+	port := schema.Properties["PORT"]
+	if port["type"] != "integer" || port["default"] != 8080.0 || port["maximum"] != 65535.0 {
+		t.Errorf("Unexpected schema for PORT %v", port)
+	}
+	timeout := schema.Properties["TIMEOUT"]
+	if timeout["type"] != "string" || timeout["default"] != "1s" {
+		t.Errorf("Unexpected schema for TIMEOUT %v", timeout)
+	}
+	if _, ok := schema.PatternProperties[`^MOD_[^=]+_ENDPOINT$`]; !ok {
+		t.Errorf("Unexpected pattern properties %v", schema.PatternProperties)
+	}
+}
+
+type exportConfig struct {
+	Mode    string         `env:",required"`
+	Timeout *time.Duration `env:"TIMEOUT"`
+	Tuning  int            `env:",docs_hidden"`
+}
+
+func (exportConfig) FieldDocsDescriptor(fieldName string) *stev.FieldDocsDescriptor {
+	if fieldName != "Mode" {
+		return nil
+	}
+	return &stev.FieldDocsDescriptor{
+		Description: "The mode.",
+		AvailableValues: map[string]stev.EnumValueDocs{
+			"fast": {ShortDesc: "Fast mode"},
+			"slow": {},
+		},
+	}
+}
+
+func TestJSONExportRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	opts := docgen.WriteOptions{FieldPrefix: "APP_", ShowHidden: true}
+	err := docgen.WriteJSON(&buf, &exportConfig{Mode: "fast"}, opts)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	export, err := docgen.ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if export.Version != docgen.DocsExportVersion || export.Prefix != "APP_" {
+		t.Errorf("Unexpected export %#v", export)
+	}
+
+	docs, err := docgen.LoadDocs(&exportConfig{Mode: "fast"}, opts)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	imported := export.FieldDocs()
+	if len(imported) != len(docs.Fields) {
+		t.Fatalf("Expected %d fields, got %d", len(docs.Fields), len(imported))
+	}
+	for i := range imported {
+		expected := docs.Fields[i]
+		expected.Type = nil
+		if !reflect.DeepEqual(imported[i], expected) {
+			t.Errorf("Round-trip failed:\n\twanted: %#v\n\thave:   %#v", expected, imported[i])
+		}
+	}
+
+	timeout := imported[1]
+	if timeout.Kind != stev.FieldKindDuration || !timeout.Nullable {
+		t.Errorf("Unexpected field %#v", timeout)
+	}
+	if !imported[2].Hidden {
+		t.Errorf("Expected hidden field")
+	}
+}
+
+func TestJSONExportWriteFormats(t *testing.T) {
+	var buf bytes.Buffer
+	opts := docgen.WriteOptions{FieldPrefix: "APP_"}
+	err := docgen.WriteJSON(&buf, &envSectionConfig{}, opts)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	export, err := docgen.ReadJSON(&buf)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	for _, name := range docgen.FormatNames() {
+		f, _ := docgen.LookupFormat(name)
+		buf.Reset()
+		if err = f.WriteDocs(&buf, export.Docs(), opts); err != nil {
+			t.Errorf("Unexpected error writing %s: %v", name, err)
+		}
+		if !strings.Contains(buf.String(), "APP_DATABASE_USER") {
+			t.Errorf("Unexpected %s output:\n%s", name, buf.String())
+		}
+	}
+}
+
+func TestReadJSONUnsupportedVersion(t *testing.T) {
+	_, err := docgen.ReadJSON(strings.NewReader(`{"version": 999, "fields": []}`))
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+type k8sConfig struct {
+	Name     string `env:",required"`
+	Password string `env:",required,secret"`
+	Port     int32
+	Greeting string
+}
+
+func TestWriteKubernetesConfigMap(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteKubernetesConfigMap(&buf, &k8sConfig{Port: 8080, Greeting: "yes: no"},
+		docgen.WriteOptions{
+			Kubernetes: docgen.KubernetesOptions{Name: "svc", Namespace: "prod"},
+		})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: svc-config
+  namespace: prod
+data:
+  GREETING: "yes: no"
+  NAME: "<REQUIRED>"
+  PORT: "8080"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: svc-secret
+  namespace: prod
+type: Opaque
+stringData:
+  PASSWORD: "<REQUIRED>"
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteKubernetesEnv(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteKubernetesEnv(&buf, &testConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !strings.Contains(buf.String(), `  # The name of the service.
+  - name: NAME
+    valueFrom:
+      configMapKeyRef:
+        name: app-config
+        key: NAME
+  - name: PORT
+`) {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+type deployConfig struct {
+	Name     string `env:",required"`
+	Greeting string
+	Price    string
+	Port     int32
+}
+
+func TestWriteDockerCompose(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteDockerCompose(&buf,
+		&deployConfig{Greeting: "hi ${there}", Price: "$5", Port: 8080},
+		docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `environment:
+  GREETING: "hi $${there}"
+  NAME: "${NAME:?required}"
+  PORT: "${PORT:-8080}"
+  PRICE: "${PRICE:-$$5}"
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteSystemdEnvironmentFile(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteSystemdEnvironmentFile(&buf,
+		&deployConfig{Greeting: "say \"hi\"\nto $USER", Port: 8080},
+		docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	out := buf.String()
+	for _, line := range []string{
+		`# GREETING="say \"hi\"\nto \$USER"`,
+		"\nNAME=\n",
+		"# PORT=8080\n",
+		"# PRICE=\n",
+	} {
+		if !strings.Contains(out, line) {
+			t.Errorf("Missing %q in output:\n%s", line, out)
+		}
+	}
+
+	buf.Reset()
+	err = docgen.WriteSystemdEnvironmentFile(&buf, &docsTagConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `
+# The timeout.
+#
+# type: string
+# since: 1.4
+# deprecated: use DEADLINE
+# example: TIMEOUT="1m 30s"
+# TIMEOUT=
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteEnvTemplateQuoting(t *testing.T) {
+	greeting := "say \"hi\" # to\n$USER 'there'"
+	var buf bytes.Buffer
+	err := docgen.WriteEnvTemplate(&buf, &deployConfig{Greeting: greeting},
+		docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	var assignment string
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, "# GREETING=") {
+			assignment = strings.TrimPrefix(line, "# ")
+		}
+	}
+	entries, err := stev.ParseEnvFile(strings.NewReader(assignment))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if entries["GREETING"] != greeting {
+		t.Errorf("Expected %q, got %q", greeting, entries["GREETING"])
+	}
+}
+
+func TestWriteShellExports(t *testing.T) {
+	loader, err := stev.NewLoader(stev.WithSources(stev.MapSource{
+		"GREETING": "it's me",
+	}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	skeleton := &deployConfig{Greeting: "hi", Price: `C:\5`}
+
+	for _, tc := range []struct {
+		format    string
+		effective bool
+		expected  []string
+	}{
+		{"sh", false, []string{
+			"# required\n# export NAME=''\n",
+			"export GREETING='hi'\n",
+		}},
+		{"sh", true, []string{
+			`export GREETING='it'\''s me'` + "\n",
+		}},
+		{"fish", true, []string{
+			`set -gx GREETING 'it\'s me'` + "\n",
+			`set -gx PRICE 'C:\\5'` + "\n",
+		}},
+		{"powershell", true, []string{
+			`$env:GREETING = 'it''s me'` + "\n",
+			`$env:PRICE = 'C:\5'` + "\n",
+		}},
+	} {
+		var buf bytes.Buffer
+		err := docgen.WriteDocs(&buf, skeleton, tc.format, docgen.WriteOptions{
+			Loader:          loader,
+			EffectiveValues: tc.effective,
+		})
+		if err != nil {
+			t.Fatalf("Expected nil, got %#v", err)
+		}
+		for _, s := range tc.expected {
+			if !strings.Contains(buf.String(), s) {
+				t.Errorf("Missing %q in %s output:\n%s", s, tc.format, buf.String())
+			}
+		}
+	}
+}
+
+func TestWriteWithTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("docs").Funcs(docgen.TemplateFuncs()).Parse(
+		`{{range .Sections}}[{{.Title}}]
+{{range .OrderedFields}}{{if .Description}}{{comment "; " (wrap 20 .Description)}}
+{{end}}{{lower .LookupKey}}={{quote .Value}}{{range enumValues .}} {{.}}{{end}}
+{{end}}{{end}}`))
+
+	var buf bytes.Buffer
+	err := docgen.WriteWithTemplate(&buf, &markdownConfig{Name: "a b"}, tmpl,
+		docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `[]
+name="a b"
+[Inner settings]
+; The mode.
+; Pipes | must be
+; escaped.
+inner_mode="" fast slow
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+type envSectionDatabase struct {
+	Host string
+	User string `env:",required"`
+}
+
+func (envSectionDatabase) SelfDocsDescriptor() stev.SelfDocsDescriptor {
+	return stev.SelfDocsDescriptor{ShortDesc: "Database"}
+}
+
+type envSectionConfig struct {
+	Region   string
+	Database *envSectionDatabase
+	Common   deployConfig `env:"&"`
+}
+
+func TestWriteEnvTemplateSections(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteEnvTemplate(&buf, &envSectionConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `
+# required
+# type: string
+NAME=
+
+# type: string
+# GREETING=
+
+# type: int32
+# format: integer -2147483648 to 2147483647
+# PORT=
+
+# type: string
+# PRICE=
+
+# type: string
+# REGION=
+
+########################################################################
+# Database
+#
+# prefix: DATABASE_
+# optional: only activated when any of its keys is set
+########################################################################
+
+# required
+# type: string
+DATABASE_USER=
+
+# type: string
+# DATABASE_HOST=
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+type docsTagConfig struct {
+	Timeout string `envdoc:"desc=The timeout.;example=1m 30s;since=1.4;deprecated=use DEADLINE"`
+}
+
+func TestWriteDocsTag(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteEnvTemplate(&buf, &docsTagConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `
+# The timeout.
+#
+# type: string
+# since: 1.4
+# deprecated: use DEADLINE
+# example: TIMEOUT="1m 30s"
+# TIMEOUT=
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	err = docgen.WriteMarkdown(&buf, &docsTagConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !strings.Contains(buf.String(), "| **Deprecated:** use DEADLINE<br>The timeout.<br>"+
+		"Example: `1m 30s`<br>Since: 1.4 |") {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+type placeholderConfig struct {
+	Modules map[string]*schemaModule `env:"MOD,map"`
+}
+
+func TestWriteMapEntryPlaceholders(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteEnvTemplate(&buf, &placeholderConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `
+########################################################################
+# Modules[<NAME>: *docgen_test.schemaModule]
+#
+# prefix: MOD_<NAME>_
+# key format: <NAME> is the key of an entry of Modules in upper case
+########################################################################
+
+# required
+# type: string
+# MOD_<NAME>_ENDPOINT=
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	err = docgen.WriteKubernetesConfigMap(&buf, &placeholderConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if strings.Contains(buf.String(), "<NAME>") {
+		t.Errorf("Unexpected placeholder in output:\n%s", buf.String())
+	}
+}
+
+type diffConfig struct {
+	Name     string `env:",required"`
+	Port     int16
+	Database *envSectionDatabase
+	Modules  map[string]*schemaModule `env:"MOD,map"`
+}
+
+func TestDiff(t *testing.T) {
+	env := `
+NAME=
+PORT=http
+OLD_KEY=1
+MOD_CACHE_ENDPOINT=localhost
+`
+	report, err := docgen.Diff(strings.NewReader(env), &diffConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `Missing keys:
+  DATABASE_HOST
+  DATABASE_USER
+Obsolete keys:
+  OLD_KEY
+Required keys without value:
+  NAME (required)
+Invalid values:
+  PORT: strconv.ParseInt: parsing "http": invalid syntax
+Keys of map entries not in the skeleton:
+  MOD_CACHE_ENDPOINT
+`
+	var buf bytes.Buffer
+	if err = report.WriteText(&buf); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+	if !report.HasDrift() {
+		t.Errorf("Expected drift")
+	}
+
+	env = "NAME=hello\nDATABASE_HOST=db\n"
+	report, err = docgen.Diff(strings.NewReader(env), &diffConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(report.Missing) != 2 || report.Missing[0].Key != "DATABASE_USER" ||
+		!report.Missing[0].Required || report.Missing[1].Key != "PORT" {
+		t.Errorf("Unexpected missing keys: %#v", report.Missing)
+	}
+	if !report.HasDrift() {
+		t.Errorf("Expected drift")
+	}
+
+	env = "NAME=hello\n"
+	report, err = docgen.Diff(strings.NewReader(env), &diffConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("Unexpected drift: %#v", report)
+	}
+}
+
+func TestDiffMapEntries(t *testing.T) {
+	env := "NAME=hello\nMOD_CACHE_ENDPOINT=localhost\n"
+	report, err := docgen.Diff(strings.NewReader(env), &diffConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(report.Unregistered) != 1 || report.Unregistered[0].Key != "MOD_CACHE_ENDPOINT" ||
+		len(report.Obsolete) != 0 || !report.HasDrift() {
+		t.Errorf("Unexpected report: %#v", report)
+	}
+
+	skeleton := &diffConfig{Modules: map[string]*schemaModule{"cache": {}}}
+	report, err = docgen.Diff(strings.NewReader(env), skeleton, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(report.Unregistered) != 0 || report.HasDrift() {
+		t.Errorf("Unexpected report: %#v", report)
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("NAME=hello\nPORT=70000\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	status := docgen.RunDiff([]string{"-json", path}, &stdout, &stderr,
+		&diffConfig{}, docgen.WriteOptions{})
+	if status != 1 {
+		t.Fatalf("Expected 1, got %d: %s", status, stderr.String())
+	}
+	var report docgen.DiffReport
+	if err := json.Unmarshal(stdout.Bytes(), &report); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(report.Invalid) != 1 || report.Invalid[0].Key != "PORT" ||
+		report.Invalid[0].Path != ".Port" {
+		t.Errorf("Unexpected invalid values: %#v", report.Invalid)
+	}
+
+	stdout.Reset()
+	status = docgen.RunDiff(nil, &stdout, &stderr, &diffConfig{}, docgen.WriteOptions{})
+	if status != 2 {
+		t.Errorf("Expected 2, got %d", status)
+	}
+}
+
+type diffHiddenConfig struct {
+	Name  string
+	Debug bool `env:",docs_hidden"`
+}
+
+func TestDiffHiddenKeys(t *testing.T) {
+	report, err := docgen.Diff(strings.NewReader("DEBUG=true\n"),
+		&diffHiddenConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(report.Obsolete) != 0 {
+		t.Errorf("Unexpected obsolete keys: %#v", report.Obsolete)
+	}
+
+	report, err = docgen.Diff(strings.NewReader("NAME=app\n"),
+		&diffHiddenConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !report.IsEmpty() {
+		t.Errorf("Unexpected report: %#v", report)
+	}
+}
+
+type diffBoolConfig struct {
+	Enabled bool `env:",required"`
+}
+
+func TestDiffRequiredBool(t *testing.T) {
+	report, err := docgen.Diff(strings.NewReader("ENABLED=\n"),
+		&diffBoolConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !report.IsEmpty() {
+		t.Errorf("Unexpected report: %#v", report)
+	}
+}
+
+func TestDiffIgnoresEnvironment(t *testing.T) {
+	t.Setenv("PORT", "http")
+	t.Setenv("NAME", "from-env")
+	skeleton := &diffConfig{Name: "default"}
+	report, err := docgen.Diff(strings.NewReader("NAME=hello\n"), skeleton, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if report.HasDrift() {
+		t.Errorf("Unexpected drift: %#v", report)
+	}
+	if skeleton.Name != "default" {
+		t.Errorf("Expected the skeleton untouched, got %q", skeleton.Name)
+	}
+}
+
+type mergeConfig struct {
+	Name     string `env:",required" envdoc:"desc=The name of the application."`
+	Port     int16
+	Region   string
+	Database *envSectionDatabase
+}
+
+func TestMergeEnvTemplate(t *testing.T) {
+	existing := `
+# The name.
+#
+# required
+# type: string
+NAME=my-app
+
+# Keep in sync with the load balancer.
+PORT=9000
+
+LEGACY="multi
+line"
+
+########################################################################
+# Database
+#
+# prefix: DATABASE_
+########################################################################
+
+# required
+# type: string
+DATABASE_USER=admin
+`
+	var buf bytes.Buffer
+	err := docgen.MergeEnvTemplate(&buf, strings.NewReader(existing),
+		&mergeConfig{}, docgen.WriteOptions{Version: "2.0"})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `
+# The name of the application.
+#
+# required
+# type: string
+NAME=my-app
+
+# Keep in sync with the load balancer.
+PORT=9000
+
+# type: string
+# REGION=
+
+# obsolete since 2.0: LEGACY is no longer read
+# LEGACY="multi
+# line"
+
+########################################################################
+# Database
+#
+# prefix: DATABASE_
+# optional: only activated when any of its keys is set
+########################################################################
+
+# required
+# type: string
+DATABASE_USER=admin
+
+# type: string
+# DATABASE_HOST=
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	merged := buf.String()
+	buf.Reset()
+	err = docgen.MergeEnvTemplate(&buf, strings.NewReader(merged),
+		&mergeConfig{}, docgen.WriteOptions{Version: "2.1"})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if buf.String() != merged {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestMergeEnvTemplateEmpty(t *testing.T) {
+	var template bytes.Buffer
+	err := docgen.WriteEnvTemplate(&template, &envSectionConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	for _, existing := range []string{"", template.String()} {
+		var buf bytes.Buffer
+		err = docgen.MergeEnvTemplate(&buf, strings.NewReader(existing),
+			&envSectionConfig{}, docgen.WriteOptions{})
+		if err != nil {
+			t.Fatalf("Expected nil, got %#v", err)
+		}
+		if buf.String() != template.String() {
+			t.Errorf("Unexpected output:\n%s", buf.String())
+		}
+	}
+}
+
+func TestMergeEnvTemplateLeadingKeys(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.MergeEnvTemplate(&buf, strings.NewReader("# My config.\n\nREGION=eu\n"),
+		&mergeConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `# My config.
+
+# The name of the application.
+#
+# required
+# type: string
+NAME=
+
+# type: int16
+# format: integer -32768 to 32767
+# PORT=
+
+REGION=eu
+
+########################################################################
+# Database
+#
+# prefix: DATABASE_
+# optional: only activated when any of its keys is set
+########################################################################
+
+# required
+# type: string
+DATABASE_USER=
+
+# type: string
+# DATABASE_HOST=
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestUpdateEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("NAME=app\nOLD=1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	err := docgen.UpdateEnvFile(path, &mergeConfig{}, docgen.WriteOptions{Version: "2.0"})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(content), "NAME=app\n") ||
+		!strings.Contains(string(content), "# obsolete since 2.0: OLD is no longer read\n# OLD=1\n") {
+		t.Errorf("Unexpected content:\n%s", content)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("Unexpected file mode: %v %v", fi, err)
+	}
+}
+
+func TestWriteDockerComposeOptionalSection(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteDockerCompose(&buf, &envSectionConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !strings.Contains(buf.String(), "  DATABASE_USER:\n") ||
+		!strings.Contains(buf.String(), `  NAME: "${NAME:?required}"`) {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestWriteKubernetesOptionalSection(t *testing.T) {
+	var buf bytes.Buffer
+	err := docgen.WriteKubernetesConfigMap(&buf, &envSectionConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !strings.Contains(buf.String(), `  # DATABASE_USER: ""`) ||
+		!strings.Contains(buf.String(), `  NAME: "<REQUIRED>"`) {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	err = docgen.WriteKubernetesEnv(&buf, &envSectionConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !strings.Contains(buf.String(), `        key: DATABASE_USER
+        optional: true
+`) {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestMergeEnvTemplateMapEntries(t *testing.T) {
+	existing := "MOD_CACHE_ENDPOINT=localhost\n"
+	var buf bytes.Buffer
+	err := docgen.MergeEnvTemplate(&buf, strings.NewReader(existing),
+		&placeholderConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := `# unregistered: MOD_CACHE_ENDPOINT is only read if the application creates its map entry
+MOD_CACHE_ENDPOINT=localhost
+`
+	if !strings.HasPrefix(buf.String(), expected) {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	merged := buf.String()
+	buf.Reset()
+	err = docgen.MergeEnvTemplate(&buf, strings.NewReader(merged),
+		&placeholderConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if buf.String() != merged {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+
+	skeleton := &placeholderConfig{Modules: map[string]*schemaModule{"cache": {}}}
+	buf.Reset()
+	err = docgen.MergeEnvTemplate(&buf, strings.NewReader(existing), skeleton, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if strings.Contains(buf.String(), "unregistered") {
+		t.Errorf("Unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestMergeEnvTemplateLongValue(t *testing.T) {
+	existing := "NAME=" + strings.Repeat("x", 100*1024) + "\n"
+	var buf bytes.Buffer
+	err := docgen.MergeEnvTemplate(&buf, strings.NewReader(existing),
+		&mergeConfig{}, docgen.WriteOptions{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !strings.HasPrefix(buf.String(), existing) || !strings.Contains(buf.String(), "# PORT=") {
+		t.Errorf("Unexpected output of %d bytes", buf.Len())
+	}
+}
@@ -0,0 +1,178 @@
+package docgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/rez-go/stev"
+)
+
+func init() {
+	RegisterFormat("json", FormatFunc(writeJSON))
+}
+
+// DocsExportVersion is the version of the schema of the documents
+// written by WriteJSON. It's incremented on each incompatible change to
+// the schema; new fields could be added without changing the version.
+const DocsExportVersion = 1
+
+// DocsExport is the machine-readable form of the docs.
+type DocsExport struct {
+	Version int             `json:"version"`
+	Prefix  string          `json:"prefix,omitempty"`
+	Fields  []ExportedField `json:"fields"`
+}
+
+// ExportedField is the machine-readable form of stev.FieldDocs.
+type ExportedField struct {
+	Key         string              `json:"key"`
+	Path        string              `json:"path"`
+	Kind        stev.FieldKind      `json:"kind"`
+	GoType      string              `json:"go_type,omitempty"`
+	TypeHint    string              `json:"type_hint,omitempty"`
+	Nullable    bool                `json:"nullable,omitempty"`
+	Placeholder bool                `json:"placeholder,omitempty"`
+	Required    bool                `json:"required,omitempty"`
+	Hidden      bool                `json:"hidden,omitempty"`
+	Secret      bool                `json:"secret,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Default     string              `json:"default,omitempty"`
+	Example     string              `json:"example,omitempty"`
+	Since       string              `json:"since,omitempty"`
+	Deprecated  string              `json:"deprecated,omitempty"`
+	Enum        []ExportedEnumValue `json:"enum,omitempty"`
+}
+
+// ExportedEnumValue is the machine-readable form of an available value
+// of a field.
+type ExportedEnumValue struct {
+	Value     string `json:"value"`
+	ShortDesc string `json:"short_desc,omitempty"`
+}
+
+// WriteJSON writes the docs of skeleton in the versioned JSON format
+// described by DocsExport. Use ReadJSON to read it back.
+func WriteJSON(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "json", opts)
+}
+
+func writeJSON(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(NewDocsExport(docs))
+}
+
+// NewDocsExport converts docs into its machine-readable form.
+func NewDocsExport(docs *Docs) *DocsExport {
+	export := &DocsExport{
+		Version: DocsExportVersion,
+		Prefix:  docs.Prefix,
+		Fields:  make([]ExportedField, 0, len(docs.Fields)),
+	}
+	for _, fd := range docs.Fields {
+		ef := ExportedField{
+			Key:         fd.LookupKey,
+			Path:        fd.Path,
+			Kind:        fd.Kind,
+			GoType:      fd.DataType,
+			TypeHint:    fd.TypeHint,
+			Nullable:    fd.Nullable,
+			Placeholder: fd.Placeholder,
+			Required:    fd.Required,
+			Hidden:      fd.Hidden,
+			Secret:      fd.Secret,
+			Description: fd.Description,
+			Default:     fd.Value,
+			Example:     fd.Example,
+			Since:       fd.Since,
+			Deprecated:  fd.Deprecated,
+		}
+		if ef.Kind == "" {
+			ef.Kind = stev.FieldKindUnknown
+		}
+		enumVals := make([]string, 0, len(fd.AvailableValues))
+		for k := range fd.AvailableValues {
+			enumVals = append(enumVals, k)
+		}
+		sort.Strings(enumVals)
+		for _, v := range enumVals {
+			ef.Enum = append(ef.Enum, ExportedEnumValue{
+				Value:     v,
+				ShortDesc: fd.AvailableValues[v].ShortDesc,
+			})
+		}
+		export.Fields = append(export.Fields, ef)
+	}
+	return export
+}
+
+// ReadJSON reads the docs written by WriteJSON. It returns an error if
+// the document was written with a newer, incompatible, version.
+func ReadJSON(reader io.Reader) (*DocsExport, error) {
+	var export DocsExport
+	if err := json.NewDecoder(reader).Decode(&export); err != nil {
+		return nil, fmt.Errorf("docgen: %w", err)
+	}
+	if export.Version < 1 || export.Version > DocsExportVersion {
+		return nil, fmt.Errorf("docgen: unsupported docs export version %d", export.Version)
+	}
+	return &export, nil
+}
+
+// FieldDocs converts the exported fields back into stev.FieldDocs. Note
+// that the Go types are not available; only their names in DataType.
+func (export *DocsExport) FieldDocs() []stev.FieldDocs {
+	fieldDocs := make([]stev.FieldDocs, 0, len(export.Fields))
+	for _, ef := range export.Fields {
+		fd := stev.FieldDocs{
+			LookupKey:   ef.Key,
+			DataType:    ef.GoType,
+			TypeHint:    ef.TypeHint,
+			Kind:        ef.Kind,
+			Nullable:    ef.Nullable,
+			Placeholder: ef.Placeholder,
+			Hidden:      ef.Hidden,
+			Secret:      ef.Secret,
+			Required:    ef.Required,
+			Description: ef.Description,
+			Value:       ef.Default,
+			Example:     ef.Example,
+			Since:       ef.Since,
+			Deprecated:  ef.Deprecated,
+			Path:        ef.Path,
+		}
+		if len(ef.Enum) > 0 {
+			fd.AvailableValues = map[string]stev.EnumValueDocs{}
+			for _, ev := range ef.Enum {
+				fd.AvailableValues[ev.Value] = stev.EnumValueDocs{ShortDesc: ev.ShortDesc}
+			}
+		}
+		fieldDocs = append(fieldDocs, fd)
+	}
+	return fieldDocs
+}
+
+// Docs converts the export back into Docs, which could be written with
+// the other formats. The export doesn't hold the sections; all the
+// fields are put in the root section.
+func (export *DocsExport) Docs() *Docs {
+	docs := &Docs{
+		Prefix: export.Prefix,
+		Fields: export.FieldDocs(),
+	}
+	buildSections(docs, &stev.DocsSection{
+		Prefix: export.Prefix,
+		Fields: docs.Fields,
+	})
+	return docs
+}
@@ -0,0 +1,147 @@
+package docgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrStaleSection is returned by CheckFileSection when the content of
+// the section doesn't match the generated docs.
+var ErrStaleSection = errors.New("docgen: section is stale")
+
+// SectionMarkers returns the begin and the end markers of the section
+// identified by marker. An empty marker identifies the unnamed section,
+// i.e., the one enclosed by <!-- stev:begin --> and <!-- stev:end -->.
+func SectionMarkers(marker string) (begin, end string) {
+	if marker == "" {
+		return "<!-- stev:begin -->", "<!-- stev:end -->"
+	}
+	return "<!-- stev:begin " + marker + " -->", "<!-- stev:end " + marker + " -->"
+}
+
+// UpdateFileSection replaces the content of the section identified by
+// marker in the file located at path with the docs of skeleton generated
+// in the format. The file is left untouched if it's already up to date.
+//
+// It's intended to be called from a command invoked by go generate to
+// keep the documentation in sync with the executable.
+func UpdateFileSection(
+	path string,
+	marker string,
+	skeleton interface{},
+	format string,
+	opts WriteOptions,
+) error {
+	content, updated, err := renderFileSection(path, marker, skeleton, format, opts)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(content, updated) {
+		return nil
+	}
+	return replaceFile(path, updated)
+}
+
+// replaceFile replaces the content of the file located at path,
+// keeping its mode. The file is replaced at once so that it's never
+// partially written.
+func replaceFile(path string, content []byte) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(content); err == nil {
+		err = tmp.Chmod(fi.Mode())
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	return nil
+}
+
+// CheckFileSection is the check mode of UpdateFileSection. It returns
+// ErrStaleSection if the file is not up to date. It doesn't modify
+// the file.
+func CheckFileSection(
+	path string,
+	marker string,
+	skeleton interface{},
+	format string,
+	opts WriteOptions,
+) error {
+	content, updated, err := renderFileSection(path, marker, skeleton, format, opts)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(content, updated) {
+		return fmt.Errorf("%w (file %s)", ErrStaleSection, path)
+	}
+	return nil
+}
+
+// renderFileSection returns the current content of the file and the
+// content with the section replaced.
+func renderFileSection(
+	path string,
+	marker string,
+	skeleton interface{},
+	format string,
+	opts WriteOptions,
+) (content, updated []byte, err error) {
+	content, err = os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("docgen: %w", err)
+	}
+	beginMarker, endMarker := SectionMarkers(marker)
+	beginIdx := bytes.Index(content, []byte(beginMarker))
+	if beginIdx < 0 {
+		return nil, nil, fmt.Errorf("docgen: marker %s not found (file %s)", beginMarker, path)
+	}
+	bodyIdx := beginIdx + len(beginMarker)
+	endIdx := bytes.Index(content[bodyIdx:], []byte(endMarker))
+	if endIdx < 0 {
+		return nil, nil, fmt.Errorf("docgen: marker %s not found (file %s)", endMarker, path)
+	}
+	endIdx += bodyIdx
+
+	var buf bytes.Buffer
+	if err = WriteDocs(&buf, skeleton, format, opts); err != nil {
+		return nil, nil, err
+	}
+	generated := strings.Trim(buf.String(), "\n")
+	// Other formats are shown verbatim when embedded in Markdown
+	if format != "markdown" && isMarkdownFile(path) {
+		generated = "```\n" + generated + "\n```"
+	}
+
+	updated = make([]byte, 0, len(content)+len(generated))
+	updated = append(updated, content[:bodyIdx]...)
+	updated = append(updated, "\n"...)
+	updated = append(updated, generated...)
+	updated = append(updated, "\n"...)
+	updated = append(updated, content[endIdx:]...)
+	return content, updated, nil
+}
+
+func isMarkdownFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rez-go/stev"
+)
+
+// WriteOptions holds the options shared by all the formats.
+type WriteOptions struct {
+	FieldPrefix string
+
+	// By default, the fields are sorted alphabetically by the keys. If it's
+	// prefered to keep their order as found in the structs, set this option
+	// to true.
+	OriginalOrdering bool
+
+	// If set to true, the path to each field will be printed in the output.
+	ShowPaths bool
+
+	// If set to true, the fields with the docs_hidden tag option are
+	// included.
+	ShowHidden bool
+
+	// By default, the shell formats are filled with the default values.
+	// If set to true, they are filled with the values the loader would
+	// load, e.g., from the process environment, falling back to the
+	// default values for the keys which are not set.
+	EffectiveValues bool
+
+	// The version of the application, e.g., to mark the keys which are
+	// made obsolete by the version in MergeEnvTemplate.
+	Version string
+
+	// Options for the Kubernetes formats.
+	Kubernetes KubernetesOptions
+
+	// The loader used to generate the docs. It should be the same loader
+	// the application uses to load the configuration. If it's nil, the
+	// default loader is used.
+	Loader *stev.Loader
+}
+
+// Docs holds the documentation of a skeleton to be written by a Format.
+type Docs struct {
+	Prefix   string
+	Skeleton interface{}
+
+	// Fields are sorted as specified in the WriteOptions.
+	Fields []stev.FieldDocs
+
+	// Root is the section of the skeleton itself. The fields are
+	// grouped by the structs containing them.
+	Root *Section
+
+	// Field paths to the sections containing them.
+	sectionByPath map[string]*Section
+}
+
+// Sections returns all the sections which have fields, the root first.
+// A nested section comes after its parent.
+func (d *Docs) Sections() []*Section {
+	var sections []*Section
+	d.Root.Walk(func(s *Section) {
+		if len(s.OrderedFields) > 0 {
+			sections = append(sections, s)
+		}
+	})
+	return sections
+}
+
+// ConcreteFields returns the fields which are not in map-entry
+// placeholders, i.e., whose keys could be set as they are.
+func (d *Docs) ConcreteFields() []stev.FieldDocs {
+	fields := make([]stev.FieldDocs, 0, len(d.Fields))
+	for _, fd := range d.Fields {
+		if !fd.Placeholder {
+			fields = append(fields, fd)
+		}
+	}
+	return fields
+}
+
+// SectionOf returns the section containing the field.
+func (d *Docs) SectionOf(fd stev.FieldDocs) *Section {
+	return d.sectionByPath[fd.Path]
+}
+
+// IsRequired returns true if the field must always be set. The required
+// fields of the optional sections are only required if the section is
+// used, thus they are not.
+func (d *Docs) IsRequired(fd stev.FieldDocs) bool {
+	return fd.Required && !d.SectionOf(fd).IsOptional()
+}
+
+// Format writes the documentation in a specific output format.
+type Format interface {
+	WriteDocs(w io.Writer, docs *Docs, opts WriteOptions) error
+}
+
+// FormatFunc adapts a function into a Format.
+type FormatFunc func(w io.Writer, docs *Docs, opts WriteOptions) error
+
+// WriteDocs calls fn.
+func (fn FormatFunc) WriteDocs(w io.Writer, docs *Docs, opts WriteOptions) error {
+	return fn(w, docs, opts)
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]Format{}
+)
+
+// RegisterFormat makes a format available by the provided name. If
+// RegisterFormat is called twice with the same name or if format is nil,
+// it panics.
+func RegisterFormat(name string, format Format) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	if format == nil {
+		panic("docgen: RegisterFormat format is nil")
+	}
+	if _, dup := formats[name]; dup {
+		panic("docgen: RegisterFormat called twice for format " + name)
+	}
+	formats[name] = format
+}
+
+// LookupFormat returns the format registered with the name.
+func LookupFormat(name string) (Format, bool) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	f, ok := formats[name]
+	return f, ok
+}
+
+// FormatNames returns a sorted list of the names of the registered
+// formats.
+func FormatNames() []string {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteDocs writes the documentation of skeleton through writer in the
+// format registered with the name format.
+func WriteDocs(
+	writer io.Writer,
+	skeleton interface{},
+	format string,
+	opts WriteOptions,
+) error {
+	f, ok := LookupFormat(format)
+	if !ok {
+		return fmt.Errorf("docgen: unknown format %q (available: %s)",
+			format, strings.Join(FormatNames(), ", "))
+	}
+	return WriteDocsWithFormat(writer, skeleton, f, opts)
+}
+
+// WriteDocsWithFormat writes the documentation of skeleton through
+// writer using f.
+func WriteDocsWithFormat(
+	writer io.Writer,
+	skeleton interface{},
+	f Format,
+	opts WriteOptions,
+) error {
+	docs, err := LoadDocs(skeleton, opts)
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	ew := &errWriter{w: writer}
+	err = f.WriteDocs(ew, docs, opts)
+	if err == nil {
+		err = ew.err
+	}
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	return nil
+}
+
+// LoadDocs generates the documentation of skeleton.
+func LoadDocs(skeleton interface{}, opts WriteOptions) (*Docs, error) {
+	treeFunc := stev.DocsTree
+	if opts.ShowHidden {
+		treeFunc = stev.AllDocsTree
+	}
+	if l := opts.Loader; l != nil {
+		treeFunc = l.DocsTree
+		if opts.ShowHidden {
+			treeFunc = l.AllDocsTree
+		}
+	}
+	tree, fieldDocs, err := treeFunc(opts.FieldPrefix, skeleton)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.OriginalOrdering {
+		sort.SliceStable(fieldDocs, func(i, j int) bool {
+			return strings.Compare(fieldDocs[i].LookupKey, fieldDocs[j].LookupKey) < 0
+		})
+	}
+
+	docs := &Docs{
+		Prefix:   opts.FieldPrefix,
+		Skeleton: skeleton,
+		Fields:   fieldDocs,
+	}
+	buildSections(docs, tree)
+	return docs, nil
+}
+
+// errWriter keeps the first error from the underlying writer so that
+// the formats don't need to check the result of every write.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) Write(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+	n, err := ew.w.Write(p)
+	if err != nil {
+		ew.err = err
+	}
+	return n, err
+}
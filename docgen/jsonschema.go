@@ -0,0 +1,218 @@
+package docgen
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rez-go/stev"
+)
+
+func init() {
+	RegisterFormat("jsonschema", FormatFunc(writeJSONSchema))
+}
+
+// JSONSchemaDialect is the JSON Schema dialect of the schemas generated
+// by WriteJSONSchema.
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// WriteJSONSchema writes a JSON Schema which describes the env map, i.e.,
+// the key-value pairs, accepted by the skeleton. The schema lets external
+// tools validate env files without running the executable.
+//
+// Each key is described with the JSON type derived from the Go type of
+// the field, its default value, description and the available values.
+// Keys of map entries are also described with patterns so that entries
+// which are not in the skeleton are recognized.
+func WriteJSONSchema(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "jsonschema", opts)
+}
+
+type jsonSchema struct {
+	Schema            string                 `json:"$schema,omitempty"`
+	Title             string                 `json:"title,omitempty"`
+	Description       string                 `json:"description,omitempty"`
+	Type              string                 `json:"type,omitempty"`
+	Pattern           string                 `json:"pattern,omitempty"`
+	Minimum           *float64               `json:"minimum,omitempty"`
+	Maximum           *float64               `json:"maximum,omitempty"`
+	Enum              []interface{}          `json:"enum,omitempty"`
+	Default           interface{}            `json:"default,omitempty"`
+	Examples          []interface{}          `json:"examples,omitempty"`
+	Deprecated        bool                   `json:"deprecated,omitempty"`
+	Properties        map[string]*jsonSchema `json:"properties,omitempty"`
+	PatternProperties map[string]*jsonSchema `json:"patternProperties,omitempty"`
+	Required          []string               `json:"required,omitempty"`
+	GoType            string                 `json:"x-go-type,omitempty"`
+	TypeHint          string                 `json:"x-type-hint,omitempty"`
+	Path              string                 `json:"x-path,omitempty"`
+	Since             string                 `json:"x-since,omitempty"`
+}
+
+func writeJSONSchema(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	schema := &jsonSchema{
+		Schema:     JSONSchemaDialect,
+		Type:       "object",
+		Properties: map[string]*jsonSchema{},
+	}
+	if self := docs.Root.Self; self != nil {
+		schema.Title = self.ShortDesc
+	}
+
+	for _, fd := range docs.Fields {
+		prop := jsonSchemaForField(fd)
+		if opts.ShowPaths {
+			prop.Path = fd.Path
+		}
+		if fd.Placeholder {
+			if schema.PatternProperties == nil {
+				schema.PatternProperties = map[string]*jsonSchema{}
+			}
+			schema.PatternProperties[placeholderKeyPattern(fd.LookupKey)] = prop
+			continue
+		}
+		schema.Properties[fd.LookupKey] = prop
+		if docs.IsRequired(fd) {
+			schema.Required = append(schema.Required, fd.LookupKey)
+		}
+	}
+	sort.Strings(schema.Required)
+
+	enc := json.NewEncoder(writer)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// durationPattern matches the strings accepted by time.ParseDuration.
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|μs|ms|s|m|h))+)$`
+
+func jsonSchemaForField(fd stev.FieldDocs) *jsonSchema {
+	s := &jsonSchema{
+		Description: fd.Description,
+		GoType:      fd.DataType,
+		TypeHint:    fd.TypeHint,
+	}
+
+	t := fd.Type
+	if t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch fd.Kind {
+	case stev.FieldKindBool:
+		s.Type = "boolean"
+	case stev.FieldKindInteger:
+		s.Type = "integer"
+		s.Minimum, s.Maximum = integerRange(t)
+	case stev.FieldKindNumber:
+		s.Type = "number"
+	case stev.FieldKindDuration:
+		// Note that the format "duration" of JSON Schema is for
+		// ISO 8601 durations, which are not what we accept.
+		s.Type = "string"
+		s.Pattern = durationPattern
+	default:
+		s.Type = "string"
+	}
+
+	if fd.Value != "" {
+		s.Default = jsonSchemaValue(s.Type, fd.Value)
+	}
+	if fd.Example != "" {
+		s.Examples = []interface{}{jsonSchemaValue(s.Type, fd.Example)}
+	}
+	if fd.Deprecated != "" {
+		// The reason has no place of its own in JSON Schema
+		s.Deprecated = true
+		s.Description = strings.TrimSpace(s.Description +
+			"\n\nDeprecated: " + fd.Deprecated)
+	}
+	s.Since = fd.Since
+	if len(fd.AvailableValues) > 0 {
+		enumVals := make([]string, 0, len(fd.AvailableValues))
+		for k := range fd.AvailableValues {
+			enumVals = append(enumVals, k)
+		}
+		sort.Strings(enumVals)
+		for _, v := range enumVals {
+			s.Enum = append(s.Enum, jsonSchemaValue(s.Type, v))
+		}
+	}
+	return s
+}
+
+// integerRange returns the range of the values of the integer type t.
+func integerRange(t reflect.Type) (min, max *float64) {
+	if t == nil {
+		return nil, nil
+	}
+	bits := float64(t.Bits())
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if bits < 64 {
+			minVal, maxVal := -math.Pow(2, bits-1), math.Pow(2, bits-1)-1
+			return &minVal, &maxVal
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minVal := 0.0
+		if bits < 64 {
+			maxVal := math.Pow(2, bits) - 1
+			return &minVal, &maxVal
+		}
+		return &minVal, nil
+	}
+	return nil, nil
+}
+
+// jsonSchemaValue converts the string representation of a value into
+// the JSON value of the type.
+func jsonSchemaValue(jsonType, strVal string) interface{} {
+	switch jsonType {
+	case "boolean":
+		if v, err := strconv.ParseBool(strVal); err == nil {
+			return v
+		}
+	case "integer":
+		if v, err := strconv.ParseInt(strVal, 0, 64); err == nil {
+			return v
+		}
+		if v, err := strconv.ParseUint(strVal, 0, 64); err == nil {
+			return v
+		}
+	case "number":
+		if v, err := strconv.ParseFloat(strVal, 64); err == nil {
+			return v
+		}
+	}
+	return strVal
+}
+
+// mapEntryPlaceholderRegexp matches the map-entry placeholders, e.g.,
+// <NAME> or <NAME2>, in the keys of the fields of placeholder entries.
+var mapEntryPlaceholderRegexp = regexp.MustCompile(`<NAME[0-9]*>`)
+
+// placeholderKeyPattern returns the regular expression for the keys of
+// the field in all the entries of the maps containing it.
+func placeholderKeyPattern(key string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range mapEntryPlaceholderRegexp.FindAllStringIndex(key, -1) {
+		sb.WriteString(regexp.QuoteMeta(key[last:loc[0]]))
+		sb.WriteString("[^=]+")
+		last = loc[1]
+	}
+	sb.WriteString(regexp.QuoteMeta(key[last:]))
+	return "^" + sb.String() + "$"
+}
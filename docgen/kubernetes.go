@@ -0,0 +1,219 @@
+package docgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/rez-go/stev"
+)
+
+func init() {
+	RegisterFormat("k8s-env", FormatFunc(writeKubernetesEnv))
+	RegisterFormat("k8s-configmap", FormatFunc(writeKubernetesConfigMap))
+}
+
+// KubernetesOptions holds the options for the Kubernetes formats.
+type KubernetesOptions struct {
+	// The base name of the resources. The ConfigMap is named with
+	// the suffix -config and the Secret with the suffix -secret. If
+	// it's empty, "app" is used.
+	Name string
+
+	// The namespace of the resources. Omitted if it's empty.
+	Namespace string
+
+	// The value for the required fields which don't have a default
+	// value. If it's empty, KubernetesPlaceholderDefault is used.
+	Placeholder string
+}
+
+// KubernetesPlaceholderDefault is the value put for the required fields
+// which don't have a default value.
+const KubernetesPlaceholderDefault = "<REQUIRED>"
+
+func (opts KubernetesOptions) configMapName() string {
+	return opts.baseName() + "-config"
+}
+
+func (opts KubernetesOptions) secretName() string {
+	return opts.baseName() + "-secret"
+}
+
+func (opts KubernetesOptions) baseName() string {
+	if opts.Name == "" {
+		return "app"
+	}
+	return opts.Name
+}
+
+func (opts KubernetesOptions) placeholder() string {
+	if opts.Placeholder == "" {
+		return KubernetesPlaceholderDefault
+	}
+	return opts.Placeholder
+}
+
+// WriteKubernetesEnv writes the env section of a container spec. The
+// fields with the secret tag option refer to the Secret and the rest
+// refer to the ConfigMap written by WriteKubernetesConfigMap. The
+// references for the fields which are not required are optional.
+func WriteKubernetesEnv(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "k8s-env", opts)
+}
+
+// WriteKubernetesConfigMap writes a ConfigMap and a Secret holding the
+// values of the fields. The fields with the secret tag option are put
+// into the Secret. Required fields which don't have a default value get
+// a placeholder value while the other fields without a value are
+// commented out.
+func WriteKubernetesConfigMap(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "k8s-configmap", opts)
+}
+
+func writeKubernetesEnv(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	k8sOpts := opts.Kubernetes
+	fmt.Fprintln(writer, "env:")
+	for _, fd := range docs.ConcreteFields() {
+		writeYAMLComment(writer, "  ", fd.Description)
+		refKind, refName := "configMapKeyRef", k8sOpts.configMapName()
+		if fd.Secret {
+			refKind, refName = "secretKeyRef", k8sOpts.secretName()
+		}
+		fmt.Fprintf(writer, "  - name: %s\n", yamlString(fd.LookupKey))
+		fmt.Fprintf(writer, "    valueFrom:\n")
+		fmt.Fprintf(writer, "      %s:\n", refKind)
+		fmt.Fprintf(writer, "        name: %s\n", yamlString(refName))
+		fmt.Fprintf(writer, "        key: %s\n", yamlString(fd.LookupKey))
+		if !docs.IsRequired(fd) {
+			fmt.Fprintf(writer, "        optional: true\n")
+		}
+	}
+	return nil
+}
+
+func writeKubernetesConfigMap(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	k8sOpts := opts.Kubernetes
+	var configFields, secretFields []stev.FieldDocs
+	for _, fd := range docs.ConcreteFields() {
+		if fd.Secret {
+			secretFields = append(secretFields, fd)
+		} else {
+			configFields = append(configFields, fd)
+		}
+	}
+
+	fmt.Fprintln(writer, "apiVersion: v1")
+	fmt.Fprintln(writer, "kind: ConfigMap")
+	writeKubernetesMetadata(writer, k8sOpts.configMapName(), k8sOpts.Namespace)
+	writeKubernetesData(writer, "data", docs, configFields, k8sOpts)
+
+	if len(secretFields) > 0 {
+		fmt.Fprintln(writer, "---")
+		fmt.Fprintln(writer, "apiVersion: v1")
+		fmt.Fprintln(writer, "kind: Secret")
+		writeKubernetesMetadata(writer, k8sOpts.secretName(), k8sOpts.Namespace)
+		fmt.Fprintln(writer, "type: Opaque")
+		writeKubernetesData(writer, "stringData", docs, secretFields, k8sOpts)
+	}
+	return nil
+}
+
+func writeKubernetesMetadata(writer io.Writer, name, namespace string) {
+	fmt.Fprintln(writer, "metadata:")
+	fmt.Fprintf(writer, "  name: %s\n", yamlString(name))
+	if namespace != "" {
+		fmt.Fprintf(writer, "  namespace: %s\n", yamlString(namespace))
+	}
+}
+
+func writeKubernetesData(
+	writer io.Writer,
+	section string,
+	docs *Docs,
+	fields []stev.FieldDocs,
+	k8sOpts KubernetesOptions,
+) {
+	if len(fields) == 0 {
+		fmt.Fprintf(writer, "%s: {}\n", section)
+		return
+	}
+	fmt.Fprintf(writer, "%s:\n", section)
+	for _, fd := range fields {
+		writeYAMLComment(writer, "  ", fd.Description)
+		switch {
+		case fd.Value != "":
+			fmt.Fprintf(writer, "  %s: %s\n", yamlString(fd.LookupKey), yamlString(fd.Value))
+		case docs.IsRequired(fd):
+			fmt.Fprintf(writer, "  %s: %s\n", yamlString(fd.LookupKey), yamlString(k8sOpts.placeholder()))
+		default:
+			fmt.Fprintf(writer, "  # %s: \"\"\n", yamlString(fd.LookupKey))
+		}
+	}
+}
+
+// writeYAMLComment writes text as a wrapped YAML comment.
+func writeYAMLComment(writer io.Writer, indent, text string) {
+	if text == "" {
+		return
+	}
+	for _, l := range strings.Split(wordwrap.WrapString(text, 72), "\n") {
+		fmt.Fprintf(writer, "%s# %s\n", indent, l)
+	}
+}
+
+// yamlString returns s as a double-quoted YAML scalar. Strings which are
+// plain identifiers are returned as they are.
+func yamlString(s string) string {
+	if isYAMLPlainSafe(s) {
+		return s
+	}
+	// JSON strings are valid YAML double-quoted scalars
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func isYAMLPlainSafe(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r == '_':
+		case (r >= '0' && r <= '9') || r == '-' || r == '.':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	// These would be resolved as booleans or null
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/rez-go/stev"
+)
+
+func init() {
+	RegisterFormat("markdown", FormatFunc(writeMarkdown))
+}
+
+// WriteMarkdown writes the documentation of skeleton as a Markdown
+// reference. The fields are presented as tables, one for each struct.
+func WriteMarkdown(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "markdown", opts)
+}
+
+func writeMarkdown(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	for i, sec := range docs.Sections() {
+		if i > 0 {
+			fmt.Fprintln(writer)
+		}
+		if sec.Path != "" {
+			fmt.Fprintf(writer, "## %s\n\n", markdownText(sec.Title()))
+			fmt.Fprintf(writer, "Path: %s\n\n", markdownCode(sec.Path))
+			if sec.MapEntry != nil && sec.MapEntry.Placeholder {
+				fmt.Fprintf(writer, "Key format: %s\n\n", markdownText(sec.MapEntry.KeyFormat))
+			}
+		} else if sec.Self != nil && sec.Self.ShortDesc != "" {
+			fmt.Fprintf(writer, "%s\n\n", markdownText(sec.Self.ShortDesc))
+		}
+
+		if opts.ShowPaths {
+			fmt.Fprintln(writer, "| Key | Type | Required | Default | Description | Path |")
+			fmt.Fprintln(writer, "| --- | --- | --- | --- | --- | --- |")
+		} else {
+			fmt.Fprintln(writer, "| Key | Type | Required | Default | Description |")
+			fmt.Fprintln(writer, "| --- | --- | --- | --- | --- |")
+		}
+		for _, fd := range sec.OrderedFields {
+			required := ""
+			if fd.Required {
+				required = "yes"
+			}
+			defVal := ""
+			if fd.Value != "" {
+				defVal = markdownCode(fd.Value)
+			}
+			fmt.Fprintf(writer, "| %s | %s | %s | %s | %s |",
+				markdownCode(fd.LookupKey),
+				markdownType(fd),
+				required,
+				defVal,
+				markdownDescription(fd))
+			if opts.ShowPaths {
+				fmt.Fprintf(writer, " %s |", markdownCode(fd.Path))
+			}
+			fmt.Fprintln(writer)
+		}
+	}
+	return nil
+}
+
+// markdownType renders the Go type of a field along with its type hint
+// for a table cell.
+func markdownType(fd stev.FieldDocs) string {
+	if fd.TypeHint == "" || fd.TypeHint == fd.DataType {
+		return markdownCode(fd.DataType)
+	}
+	return markdownCode(fd.DataType) + "<br>" + markdownText(fd.TypeHint)
+}
+
+// markdownDescription renders the description and the available values
+// of a field for a table cell.
+func markdownDescription(fd stev.FieldDocs) string {
+	var parts []string
+	if fd.Deprecated != "" {
+		parts = append(parts, "**Deprecated:** "+markdownText(fd.Deprecated))
+	}
+	if fd.Description != "" {
+		parts = append(parts, markdownText(fd.Description))
+	}
+	if fd.Example != "" {
+		parts = append(parts, "Example: "+markdownCode(fd.Example))
+	}
+	if fd.Since != "" {
+		parts = append(parts, "Since: "+markdownText(fd.Since))
+	}
+	if len(fd.AvailableValues) > 0 {
+		enumVals := make([]string, 0, len(fd.AvailableValues))
+		for k := range fd.AvailableValues {
+			enumVals = append(enumVals, k)
+		}
+		sort.Strings(enumVals)
+
+		parts = append(parts, "Available values:")
+		for _, enumVal := range enumVals {
+			entry := "- " + markdownCode(enumVal)
+			if desc := fd.AvailableValues[enumVal].ShortDesc; desc != "" {
+				entry += ": " + markdownText(desc)
+			}
+			parts = append(parts, entry)
+		}
+	}
+	return strings.Join(parts, "<br>")
+}
+
+var markdownTextReplacer = strings.NewReplacer(
+	`\`, `\\`,
+	"|", `\|`,
+	"<", "&lt;",
+	">", "&gt;",
+	"\r\n", "<br>",
+	"\n", "<br>",
+)
+
+// markdownText escapes s to be used as text in a table cell.
+func markdownText(s string) string {
+	return markdownTextReplacer.Replace(strings.TrimSpace(s))
+}
+
+// markdownCode renders s as a code span which could be put in a table
+// cell.
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	// Newlines can't be represented in a code span in a table
+	s = strings.NewReplacer("\r\n", " ", "\n", " ").Replace(s)
+	// Pipes must be escaped even in code spans
+	s = strings.ReplaceAll(s, "|", `\|`)
+
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
@@ -0,0 +1,393 @@
+package docgen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/rez-go/stev"
+)
+
+// MergeEnvTemplate writes through writer the env file read from
+// existing, upgraded to the env template of skeleton. It's intended to
+// bring the keys introduced by a new version of the application into
+// the env files of its deployments.
+//
+// The assignments in the file, including those which are commented
+// out, and the comments are kept as they are. The new keys are
+// inserted with their docs after the keys which precede them in the
+// template, under the banners of their sections. The comments right
+// above a key which contain a "# type:" line are its docs; they are
+// replaced with the current ones, and so are the banners of the
+// sections. The obsolete keys, i.e., those which are no longer read,
+// are commented out with a marker which tells the Version which made
+// them obsolete. The keys of map entries which are not in the skeleton
+// are kept but marked as they are only read if the application
+// creates their entries.
+//
+// Merging an empty file gives the template as written by
+// WriteEnvTemplate, and merging the template gives it back unchanged.
+func MergeEnvTemplate(
+	writer io.Writer,
+	existing io.Reader,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	content, err := io.ReadAll(existing)
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	entries, err := stev.ParseEnvFileEntries(bytes.NewReader(content))
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	lines := splitLines(string(content))
+
+	m, err := newEnvMerge(skeleton, opts)
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	ew := &errWriter{w: writer}
+	m.write(ew, m.split(lines, entries))
+	if ew.err != nil {
+		return fmt.Errorf("docgen: %w", ew.err)
+	}
+	return nil
+}
+
+// UpdateEnvFile upgrades the env file located at path in place with
+// MergeEnvTemplate. The file is left untouched if it's already up to
+// date.
+func UpdateEnvFile(path string, skeleton interface{}, opts WriteOptions) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("docgen: %w", err)
+	}
+	var buf bytes.Buffer
+	if err = MergeEnvTemplate(&buf, bytes.NewReader(content), skeleton, opts); err != nil {
+		return err
+	}
+	if bytes.Equal(content, buf.Bytes()) {
+		return nil
+	}
+	return replaceFile(path, buf.Bytes())
+}
+
+type envMerge struct {
+	opts WriteOptions
+
+	// The fields in the order of the template.
+	fields     []envTemplateField
+	fieldByKey map[string]*envTemplateField
+
+	// The keys which are read, including those of the hidden fields
+	// and those of the map-entry placeholders.
+	known        map[string]bool
+	placeholders []*regexp.Regexp
+
+	sectionByPrefix map[string]*Section
+}
+
+func newEnvMerge(skeleton interface{}, opts WriteOptions) (*envMerge, error) {
+	docs, err := LoadDocs(skeleton, opts)
+	if err != nil {
+		return nil, err
+	}
+	allOpts := opts
+	allOpts.ShowHidden = true
+	allDocs, err := LoadDocs(skeleton, allOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &envMerge{
+		opts:            opts,
+		fields:          envTemplateFields(docs),
+		fieldByKey:      map[string]*envTemplateField{},
+		known:           map[string]bool{},
+		sectionByPrefix: map[string]*Section{},
+	}
+	for i := range m.fields {
+		f := &m.fields[i]
+		m.fieldByKey[f.LookupKey] = f
+		if f.section != nil {
+			m.sectionByPrefix[f.section.Prefix] = f.section
+		}
+	}
+	for _, fd := range allDocs.Fields {
+		m.known[fd.LookupKey] = true
+		if fd.Placeholder {
+			m.placeholders = append(m.placeholders,
+				regexp.MustCompile(placeholderKeyPattern(fd.LookupKey)))
+		}
+	}
+	return m, nil
+}
+
+// envMergeChunk is a part of the existing file.
+type envMergeChunk struct {
+	lines []string
+
+	// The field whose key is assigned in lines.
+	field *envTemplateField
+	// The lines are preceded by the docs of field, which are to be
+	// replaced.
+	docs bool
+
+	// The lines are the banner of the section.
+	banner *Section
+
+	// The key assigned in lines is no longer read.
+	obsoleteKey string
+
+	// The key assigned in lines is of a map entry which is not in the
+	// skeleton; it's only read if the application creates the entry.
+	unregisteredKey string
+}
+
+// split splits the lines of the file into chunks. Every assignment,
+// including the commented-out assignments of the keys which are read,
+// is in a chunk of its own.
+func (m *envMerge) split(lines []string, entries []stev.EnvFileEntry) []envMergeChunk {
+	entryAt := map[int]stev.EnvFileEntry{}
+	for _, e := range entries {
+		entryAt[e.Line-1] = e
+	}
+
+	var chunks []envMergeChunk
+	// The comments since the last blank line or assignment.
+	var pending []string
+	flush := func() {
+		if len(pending) > 0 {
+			chunks = append(chunks, envMergeChunk{lines: pending, banner: m.bannerOf(pending)})
+			pending = nil
+		}
+	}
+	addAssignment := func(key string, assignLines []string) {
+		if f := m.fieldByKey[key]; f != nil {
+			docs := isEnvFieldDocs(pending)
+			if !docs {
+				flush()
+			}
+			pending = nil
+			chunks = append(chunks, envMergeChunk{lines: assignLines, field: f, docs: docs})
+			return
+		}
+		c := envMergeChunk{lines: assignLines}
+		switch {
+		case m.isObsolete(key):
+			c.obsoleteKey = key
+		case m.isUnregistered(key):
+			c.unregisteredKey = key
+			// The marker from a previous merge is written again.
+			if n := len(pending); n > 0 && pending[n-1] == unregisteredMarker(key) {
+				pending = pending[:n-1]
+			}
+		}
+		flush()
+		chunks = append(chunks, c)
+	}
+
+	for i := 0; i < len(lines); i++ {
+		if e, ok := entryAt[i]; ok {
+			addAssignment(e.Key, lines[i:e.EndLine])
+			i = e.EndLine - 1
+			continue
+		}
+		line := strings.TrimSpace(lines[i])
+		if strings.HasPrefix(line, "#") {
+			if key := commentedKey(line); m.known[key] {
+				addAssignment(key, lines[i:i+1])
+			} else {
+				pending = append(pending, lines[i])
+			}
+			continue
+		}
+		flush()
+		chunks = append(chunks, envMergeChunk{lines: lines[i : i+1]})
+	}
+	flush()
+	return chunks
+}
+
+func (m *envMerge) write(w io.Writer, chunks []envMergeChunk) {
+	firstChunk := map[string]int{}
+	lastChunk := map[string]int{}
+	presentSections := map[*Section]bool{}
+	for i, c := range chunks {
+		if c.field == nil {
+			continue
+		}
+		if _, ok := firstChunk[c.field.LookupKey]; !ok {
+			firstChunk[c.field.LookupKey] = i
+		}
+		lastChunk[c.field.LookupKey] = i
+		presentSections[c.field.section] = true
+	}
+
+	// The new keys go after the last chunk of the closest preceding
+	// key in the file. Those without preceding keys go before the
+	// first chunk of the closest following key, or at the end if the
+	// file has none of the keys.
+	after := map[int][]envTemplateField{}
+	before := map[int][]envTemplateField{}
+	var leading []envTemplateField
+	anchor := -1
+	for _, f := range m.fields {
+		if i, ok := lastChunk[f.LookupKey]; ok {
+			if len(leading) > 0 {
+				before[firstChunk[f.LookupKey]] = leading
+				leading = nil
+			}
+			anchor = i
+			continue
+		}
+		if anchor < 0 {
+			leading = append(leading, f)
+		} else {
+			after[anchor] = append(after[anchor], f)
+		}
+	}
+
+	writeFields := func(fields []envTemplateField) string {
+		var buf bytes.Buffer
+		for _, f := range fields {
+			if f.first && f.section != nil && !presentSections[f.section] {
+				writeEnvBanner(&buf, f.section)
+			}
+			writeEnvField(&buf, f.FieldDocs, m.opts)
+		}
+		return buf.String()
+	}
+
+	for i, c := range chunks {
+		if fields := before[i]; len(fields) > 0 {
+			fmt.Fprintln(w, strings.TrimPrefix(writeFields(fields), "\n"))
+		}
+		switch {
+		case c.banner != nil:
+			var buf bytes.Buffer
+			writeEnvBanner(&buf, c.banner)
+			io.WriteString(w, strings.TrimPrefix(buf.String(), "\n"))
+		case c.obsoleteKey != "":
+			if m.opts.Version != "" {
+				fmt.Fprintf(w, "# obsolete since %s: %s is no longer read\n",
+					m.opts.Version, c.obsoleteKey)
+			} else {
+				fmt.Fprintf(w, "# obsolete: %s is no longer read\n", c.obsoleteKey)
+			}
+			for _, l := range c.lines {
+				fmt.Fprintln(w, "#", l)
+			}
+		case c.unregisteredKey != "":
+			fmt.Fprintln(w, unregisteredMarker(c.unregisteredKey))
+			for _, l := range c.lines {
+				fmt.Fprintln(w, l)
+			}
+		default:
+			if c.docs {
+				io.WriteString(w, envFieldDocs(c.field.FieldDocs, m.opts))
+			}
+			for _, l := range c.lines {
+				fmt.Fprintln(w, l)
+			}
+		}
+		if fields := after[i]; len(fields) > 0 {
+			io.WriteString(w, writeFields(fields))
+		}
+	}
+	if len(leading) > 0 {
+		io.WriteString(w, writeFields(leading))
+	}
+}
+
+// isObsolete returns true if the key is no longer read. The keys
+// without the prefix are never obsolete.
+func (m *envMerge) isObsolete(key string) bool {
+	return !m.known[key] && strings.HasPrefix(key, m.opts.FieldPrefix) &&
+		!m.isUnregistered(key)
+}
+
+// isUnregistered returns true if the key matches a map-entry
+// placeholder but its entry is not in the skeleton.
+func (m *envMerge) isUnregistered(key string) bool {
+	if m.known[key] || !strings.HasPrefix(key, m.opts.FieldPrefix) {
+		return false
+	}
+	for _, p := range m.placeholders {
+		if p.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// unregisteredMarker returns the comment written above the assignment
+// of an unregistered key.
+func unregisteredMarker(key string) string {
+	return "# unregistered: " + key +
+		" is only read if the application creates its map entry"
+}
+
+// bannerOf returns the section whose banner is in lines, if any.
+func (m *envMerge) bannerOf(lines []string) *Section {
+	if len(lines) < 2 || lines[0] != envBannerLine || lines[len(lines)-1] != envBannerLine {
+		return nil
+	}
+	for _, l := range lines {
+		if prefix, ok := strings.CutPrefix(l, "# prefix: "); ok {
+			return m.sectionByPrefix[prefix]
+		}
+	}
+	return nil
+}
+
+// envFieldDocs returns the docs of the field as written in the env
+// template, without the assignment.
+func envFieldDocs(fd stev.FieldDocs, opts WriteOptions) string {
+	var buf bytes.Buffer
+	writeEnvField(&buf, fd, opts)
+	s := strings.TrimPrefix(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	return s[:strings.LastIndexByte(s, '\n')+1]
+}
+
+// isEnvFieldDocs returns true if the comments are the docs of a field
+// as written in the env template.
+func isEnvFieldDocs(comments []string) bool {
+	for _, l := range comments {
+		if strings.HasPrefix(l, "# type: ") {
+			return true
+		}
+	}
+	return false
+}
+
+// commentedKey returns the key of a commented-out assignment, e.g.,
+// "# KEY=value". The result is meaningless if the comment is not an
+// assignment.
+func commentedKey(comment string) string {
+	s := strings.TrimSpace(strings.TrimLeft(comment, "#"))
+	s = strings.TrimPrefix(s, "export ")
+	eq := strings.IndexByte(s, '=')
+	if eq <= 0 {
+		return ""
+	}
+	return strings.TrimSpace(s[:eq])
+}
+
+// splitLines splits s into lines the same way as bufio.ScanLines, which
+// is used by stev.ParseEnvFileEntries, but without a limit on the
+// length of the lines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSuffix(l, "\r")
+	}
+	return lines
+}
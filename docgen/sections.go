@@ -0,0 +1,91 @@
+package docgen
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/rez-go/stev"
+)
+
+// Section holds the fields which belong to the same struct, along
+// with the sections of the structs nested in it. The information about
+// the struct itself is provided by the embedded stev.DocsSection, whose
+// Fields and Sections are in the order of the declarations.
+type Section struct {
+	*stev.DocsSection
+
+	// The section containing this one; nil for the root.
+	Parent *Section
+
+	// Fields of the struct itself, in the order of Docs.Fields.
+	OrderedFields []stev.FieldDocs
+
+	// Sections of the nested structs, ordered by the first appearance
+	// of their fields.
+	Subsections []*Section
+}
+
+// Title returns the short description of the section or, if there's
+// none, its path without the leading dot.
+func (s *Section) Title() string {
+	if s.Self != nil && s.Self.ShortDesc != "" {
+		return s.Self.ShortDesc
+	}
+	return strings.TrimPrefix(s.Path, ".")
+}
+
+// IsOptional returns true if the section, or any of its ancestors, is
+// only used when any of its keys is set, i.e., it's a pointer struct or
+// a map entry. See Docs.IsRequired.
+func (s *Section) IsOptional() bool {
+	for ; s != nil; s = s.Parent {
+		if s.Optional || s.MapEntry != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSections mirrors tree into the sections of docs and distributes
+// the fields of docs into them.
+func buildSections(docs *Docs, tree *stev.DocsSection) {
+	docs.sectionByPath = map[string]*Section{}
+	var mirror func(ds *stev.DocsSection, parent *Section) *Section
+	mirror = func(ds *stev.DocsSection, parent *Section) *Section {
+		s := &Section{DocsSection: ds, Parent: parent}
+		for _, fd := range ds.Fields {
+			docs.sectionByPath[fd.Path] = s
+		}
+		for _, sub := range ds.Sections {
+			s.Subsections = append(s.Subsections, mirror(sub, s))
+		}
+		return s
+	}
+	docs.Root = mirror(tree, nil)
+
+	firstIndex := map[*Section]int{}
+	for i, fd := range docs.Fields {
+		s := docs.sectionByPath[fd.Path]
+		s.OrderedFields = append(s.OrderedFields, fd)
+		for ; s != nil; s = s.Parent {
+			if _, seen := firstIndex[s]; !seen {
+				firstIndex[s] = i
+			}
+		}
+	}
+	docs.Root.Walk(func(s *Section) {
+		sort.SliceStable(s.Subsections, func(i, j int) bool {
+			iIdx, iOk := firstIndex[s.Subsections[i]]
+			jIdx, jOk := firstIndex[s.Subsections[j]]
+			return iOk && (!jOk || iIdx < jIdx)
+		})
+	})
+}
+
+// Walk calls fn for s and each of its descendants, parents first.
+func (s *Section) Walk(fn func(*Section)) {
+	fn(s)
+	for _, sub := range s.Subsections {
+		sub.Walk(fn)
+	}
+}
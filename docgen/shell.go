@@ -0,0 +1,140 @@
+package docgen
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/rez-go/stev"
+)
+
+func init() {
+	RegisterFormat("sh", FormatFunc(writeShellExports))
+	RegisterFormat("fish", FormatFunc(writeFishExports))
+	RegisterFormat("powershell", FormatFunc(writePowerShellExports))
+}
+
+// WriteShellExports writes a script which exports the values with
+// `export KEY='value'`, to be sourced by POSIX shells. The keys
+// without values are written commented out.
+//
+// The values are the default values, or the current ones if
+// WriteOptions.EffectiveValues is set.
+func WriteShellExports(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "sh", opts)
+}
+
+// WriteFishExports writes a script which exports the values with
+// `set -gx KEY 'value'`, to be sourced by fish. See WriteShellExports.
+func WriteFishExports(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "fish", opts)
+}
+
+// WritePowerShellExports writes a script which sets the values with
+// `$env:KEY = 'value'`, to be dot-sourced by PowerShell. See
+// WriteShellExports.
+func WritePowerShellExports(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "powershell", opts)
+}
+
+func writeShellExports(writer io.Writer, docs *Docs, opts WriteOptions) error {
+	return writeShellScript(writer, docs, opts, func(key, value string) string {
+		return "export " + key + "=" + posixQuote(value)
+	})
+}
+
+func writeFishExports(writer io.Writer, docs *Docs, opts WriteOptions) error {
+	return writeShellScript(writer, docs, opts, func(key, value string) string {
+		return "set -gx " + key + " " + fishQuote(value)
+	})
+}
+
+func writePowerShellExports(writer io.Writer, docs *Docs, opts WriteOptions) error {
+	return writeShellScript(writer, docs, opts, func(key, value string) string {
+		return powerShellEnvVar(key) + " = " + powerShellQuote(value)
+	})
+}
+
+// writeShellScript writes the assignments produced by assign. The
+// three shells share the # comment syntax.
+func writeShellScript(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+	assign func(key, value string) string,
+) error {
+	lookupEnv := stev.LookupEnv
+	if opts.Loader != nil {
+		lookupEnv = opts.Loader.LookupEnv
+	}
+	for i, fd := range docs.ConcreteFields() {
+		if i > 0 {
+			fmt.Fprintf(writer, "\n")
+		}
+		if fd.Description != "" {
+			descLines := strings.Split(wordwrap.WrapString(fd.Description, 72), "\n")
+			for _, l := range descLines {
+				fmt.Fprintln(writer, "#", l)
+			}
+		}
+		value, ok := fd.Value, fd.Value != ""
+		if opts.EffectiveValues {
+			if v, found := lookupEnv(fd.LookupKey); found {
+				value, ok = v, true
+			}
+		}
+		if !ok {
+			if fd.Required {
+				fmt.Fprintf(writer, "# required\n")
+			}
+			fmt.Fprintf(writer, "# %s\n", assign(fd.LookupKey, ""))
+			continue
+		}
+		fmt.Fprintln(writer, assign(fd.LookupKey, value))
+	}
+	return nil
+}
+
+// posixQuote single-quotes s. A single quote can't be escaped within
+// single quotes thus it's closed, escaped and reopened.
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s. Within single quotes, fish only
+// recognizes the escapes for the backslash and the single quote.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// powerShellQuote single-quotes s. Within single quotes, PowerShell
+// reads a doubled single quote as one.
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// powerShellEnvVar returns the reference to the environment variable
+// named key, using the braced syntax if key isn't a simple name.
+func powerShellEnvVar(key string) string {
+	for _, r := range key {
+		if r != '_' && !('0' <= r && r <= '9') &&
+			!('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') {
+			return "${env:" + strings.ReplaceAll(key, "}", "`}") + "}"
+		}
+	}
+	return "$env:" + key
+}
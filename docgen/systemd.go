@@ -0,0 +1,59 @@
+package docgen
+
+import (
+	"io"
+	"strings"
+)
+
+func init() {
+	RegisterFormat("systemd", FormatFunc(writeSystemdEnvironmentFile))
+}
+
+// WriteSystemdEnvironmentFile writes a file to be used with systemd's
+// EnvironmentFile= directive. It's laid out like the env template, with
+// the values quoted following systemd's rules.
+func WriteSystemdEnvironmentFile(
+	writer io.Writer,
+	skeleton interface{},
+	opts WriteOptions,
+) error {
+	return WriteDocs(writer, skeleton, "systemd", opts)
+}
+
+func writeSystemdEnvironmentFile(
+	writer io.Writer,
+	docs *Docs,
+	opts WriteOptions,
+) error {
+	for _, fd := range docs.Fields {
+		writeEnvFieldQuoted(writer, fd, opts, systemdCommentQuote)
+	}
+	return nil
+}
+
+// systemdCommentQuote is like systemdQuote but it keeps the result on
+// a single line so that it could be commented out.
+func systemdCommentQuote(s string) string {
+	return strings.ReplaceAll(systemdQuote(s), "\n", `\n`)
+}
+
+// systemdQuote quotes s, if necessary, to be read back as it is from
+// an EnvironmentFile. Within double quotes, systemd recognizes the
+// escapes for the backslash, the double quote, the dollar sign and
+// the backtick, and keeps the newlines.
+func systemdQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\r\n\"'\\$`#;") {
+		return s
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '\\', '"', '$', '`':
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,92 @@
+package docgen
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/mitchellh/go-wordwrap"
+	"github.com/rez-go/stev"
+)
+
+// TemplateData is the data a template is executed with. It's the same
+// model the built-in formats are given.
+type TemplateData struct {
+	*Docs
+
+	Options WriteOptions
+}
+
+// TemplateFuncs returns the functions available to the templates. They
+// must be added to a template before it's parsed:
+//
+//	tmpl, err := template.New("docs").
+//		Funcs(docgen.TemplateFuncs()).
+//		Parse(text)
+//
+// The functions are:
+//
+//   - wrap WIDTH TEXT: wraps TEXT at WIDTH columns.
+//   - comment PREFIX TEXT: prefixes each line of TEXT with PREFIX.
+//   - upper, lower, trim: as their counterparts in package strings.
+//   - join SEP LIST: joins the strings of LIST with SEP.
+//   - enumValues FIELD: the sorted available values of FIELD.
+//   - quote TEXT: quotes TEXT for a dotenv file.
+//   - shquote TEXT: quotes TEXT for POSIX shells.
+//   - yamlquote TEXT: quotes TEXT as a YAML string.
+//   - mdtext TEXT, mdcode TEXT: escape TEXT as Markdown text or code
+//     to be put in a table cell.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"wrap": func(width int, s string) string {
+			return wordwrap.WrapString(s, uint(width))
+		},
+		"comment": func(prefix, s string) string {
+			lines := strings.Split(s, "\n")
+			for i, l := range lines {
+				lines[i] = strings.TrimRight(prefix+l, " \t")
+			}
+			return strings.Join(lines, "\n")
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"trim":  strings.TrimSpace,
+		"join": func(sep string, elems []string) string {
+			return strings.Join(elems, sep)
+		},
+		"enumValues": func(fd stev.FieldDocs) []string {
+			vals := make([]string, 0, len(fd.AvailableValues))
+			for k := range fd.AvailableValues {
+				vals = append(vals, k)
+			}
+			sort.Strings(vals)
+			return vals
+		},
+		"quote":     dotenvQuote,
+		"shquote":   posixQuote,
+		"yamlquote": yamlString,
+		"mdtext":    markdownText,
+		"mdcode":    markdownCode,
+	}
+}
+
+// TemplateFormat returns a Format which executes tmpl with a
+// TemplateData. It could be registered with RegisterFormat.
+func TemplateFormat(tmpl *template.Template) Format {
+	return FormatFunc(func(w io.Writer, docs *Docs, opts WriteOptions) error {
+		return tmpl.Execute(w, TemplateData{Docs: docs, Options: opts})
+	})
+}
+
+// WriteWithTemplate writes the documentation of skeleton by executing
+// tmpl with a TemplateData. See TemplateFuncs for the functions which
+// could be used in tmpl.
+func WriteWithTemplate(
+	writer io.Writer,
+	skeleton interface{},
+	tmpl *template.Template,
+	opts WriteOptions,
+) error {
+	return WriteDocsWithFormat(writer, skeleton, TemplateFormat(tmpl), opts)
+}
@@ -0,0 +1,198 @@
+package stev
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DocsSection documents a struct. The sections of a DocsTree form the
+// hierarchy of the structs: the root section is the structure passed to
+// DocsTree, and each struct field or map entry gets its own section.
+type DocsSection struct {
+	// Path of the struct, in the format of FieldDocs.Path. It's empty
+	// for the root.
+	Path string
+
+	// The prefix of the keys of the fields of the struct.
+	Prefix string
+
+	// The Go type of the struct.
+	Type reflect.Type
+
+	// The docs-descriptor of the struct, if it provides one.
+	Self *SelfDocsDescriptor
+
+	// The description of the struct field, as provided by the
+	// docs-descriptors of the parent struct.
+	Description string
+
+	// The struct field is a pointer. The struct is only instantiated if
+	// any of its keys is set, thus its required fields are only
+	// required if the section is used.
+	Optional bool
+
+	// The struct field has the required tag option; at least one of the
+	// keys of the section must be set.
+	Required bool
+
+	// The struct is embedded or squashed; its keys don't have a prefix
+	// of their own.
+	Squashed bool
+
+	// Set if the section is an entry of a map field.
+	MapEntry *MapEntryDocs
+
+	// Fields of the struct itself, in the order they are declared.
+	Fields []FieldDocs
+
+	// Sections of the nested structs, in the order they are declared.
+	Sections []*DocsSection
+}
+
+// MapEntryDocs identifies the map entry a DocsSection documents.
+type MapEntryDocs struct {
+	// The name of the map field.
+	Field string
+
+	// The key of the entry in the map. For placeholders, it's the
+	// placeholder found in the keys of the fields, e.g., <NAME>.
+	Key string
+
+	// The section documents any entry of the map rather than an
+	// existing entry.
+	Placeholder bool
+
+	// For placeholders, how the placeholder is to be replaced.
+	KeyFormat string
+}
+
+// Walk calls fn for s and each of its descendants, parents first. If fn
+// returns false, the descendants of that section are skipped.
+func (s *DocsSection) Walk(fn func(*DocsSection) bool) {
+	if !fn(s) {
+		return
+	}
+	for _, sub := range s.Sections {
+		sub.Walk(fn)
+	}
+}
+
+func (s *DocsSection) addSection(sub *DocsSection) *DocsSection {
+	s.Sections = append(s.Sections, sub)
+	return sub
+}
+
+// structType dereferences t down to the struct type.
+func structType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// structPointer returns a pointer to the struct held by v, which is
+// either a struct or a pointer to a struct. A nil pointer resolves to a
+// pointer to a zero struct.
+func structPointer(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.New(v.Type().Elem()).Interface()
+		}
+		return v.Interface()
+	}
+	if v.CanAddr() {
+		return v.Addr().Interface()
+	}
+	return nil
+}
+
+// mapEntryPlaceholder returns the placeholder for the keys of the map
+// entries at the provided depth of placeholders, e.g., <NAME>, <NAME2>.
+func mapEntryPlaceholder(depth int) string {
+	if depth == 0 {
+		return "<NAME>"
+	}
+	return "<NAME" + strconv.Itoa(depth+1) + ">"
+}
+
+// mapEntryPlaceholderDocs documents the fields of any entry of the map
+// field, with their keys containing a placeholder in place of the key
+// of the entry. The type of the entries is resolved from the type of
+// the map or, if it's an interface, from the entries present in the
+// skeleton if they all have the same type.
+func (l Loader) mapEntryPlaceholderDocs(
+	fmBasePrefix string,
+	fInfo reflect.StructField,
+	fTagOpts fieldTagOpts,
+	fVal reflect.Value,
+	fieldPath string,
+	st *loadState,
+) error {
+	entryType := fInfo.Type.Elem()
+	var knownKeys []string
+	for _, entryKey := range fVal.MapKeys() {
+		knownKeys = append(knownKeys, strings.ToUpper(entryKey.String()))
+		if fInfo.Type.Elem().Kind() != reflect.Interface {
+			continue
+		}
+		t := reflect.TypeOf(fVal.MapIndex(entryKey).Interface())
+		if entryType.Kind() == reflect.Interface {
+			entryType = t
+		} else if t != entryType {
+			entryType = nil
+			break
+		}
+	}
+	if entryType == nil || entryType.Kind() != reflect.Ptr ||
+		entryType.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	// An entry type which contains, directly or not, a map of itself
+	// would be expanded endlessly. Its placeholder is documented once.
+	if st.placeholderTypes[entryType] {
+		return nil
+	}
+	sort.Strings(knownKeys)
+
+	placeholder := mapEntryPlaceholder(st.placeholderDepth)
+	keyFormat := fmt.Sprintf("%s is the key of an entry of %s in upper case",
+		placeholder, fInfo.Name)
+	if len(knownKeys) > 0 {
+		keyFormat += ", e.g., " + strings.Join(knownKeys, ", ")
+	}
+	fmPrefix := fmBasePrefix + placeholder + l.NamespaceSeparator
+	fmPath := fieldPath + "." + fInfo.Name + "[" + placeholder + ": " + entryType.String() + "]"
+	entryVal := reflect.New(entryType.Elem())
+
+	parentSection := st.section
+	st.section = parentSection.addSection(&DocsSection{
+		Path:     fmPath,
+		Prefix:   fmPrefix,
+		Type:     entryType.Elem(),
+		Self:     l.selfDocsDescriptor(entryVal.Interface()),
+		Required: fTagOpts.Required,
+		MapEntry: &MapEntryDocs{
+			Field:       fInfo.Name,
+			Key:         placeholder,
+			Placeholder: true,
+			KeyFormat:   keyFormat,
+		},
+	})
+	if st.placeholderTypes == nil {
+		st.placeholderTypes = map[reflect.Type]bool{}
+	}
+	st.placeholderTypes[entryType] = true
+	st.placeholderDepth++
+	_, err := l.loadFromEnv(fmPrefix, entryVal.Interface(), false, true, fmPath, st)
+	st.placeholderDepth--
+	delete(st.placeholderTypes, entryType)
+	st.section = parentSection
+	if err != nil {
+		return fmt.Errorf("map entry placeholder docs failed: %w (field %s)",
+			err, fInfo.Name)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package stev_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rez-go/stev"
+)
+
+type treeDatabase struct {
+	Host string `env:",required"`
+}
+
+func (treeDatabase) SelfDocsDescriptor() stev.SelfDocsDescriptor {
+	return stev.SelfDocsDescriptor{ShortDesc: "Database"}
+}
+
+type treeModule struct {
+	Enabled bool
+}
+
+type treeConfig struct {
+	Name     string
+	DB       treeDatabase `env:",required"`
+	Cache    *treeDatabase
+	Modules  map[string]*treeModule `env:",map"`
+	Embedded treeModule             `env:"&"`
+}
+
+func (treeConfig) FieldDescriptions() map[string]string {
+	return map[string]string{"Cache": "The cache server."}
+}
+
+func TestDocsTree(t *testing.T) {
+	skeleton := &treeConfig{Modules: map[string]*treeModule{"auth": {}}}
+	root, fields, err := stev.DocsTree("APP_", skeleton)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	fieldDocs, err := stev.Docs("APP_", skeleton)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !reflect.DeepEqual(fields, fieldDocs) {
+		t.Errorf("Expected the fields of Docs, got %#v", fields)
+	}
+	assertStrEq(t, root.Prefix, "APP_")
+	if len(root.Fields) != 1 || root.Fields[0].LookupKey != "APP_NAME" {
+		t.Fatalf("Unexpected root fields %#v", root.Fields)
+	}
+	if len(root.Sections) != 5 {
+		t.Fatalf("Expected 5 sections, got %d", len(root.Sections))
+	}
+
+	db := root.Sections[0]
+	assertStrEq(t, db.Path, ".DB")
+	assertStrEq(t, db.Prefix, "APP_DB_")
+	if !db.Required || db.Optional || db.Self == nil || db.Self.ShortDesc != "Database" {
+		t.Errorf("Unexpected section %#v", db)
+	}
+	if len(db.Fields) != 1 || db.Fields[0].LookupKey != "APP_DB_HOST" {
+		t.Errorf("Unexpected fields %#v", db.Fields)
+	}
+
+	cache := root.Sections[1]
+	if !cache.Optional || cache.Required {
+		t.Errorf("Unexpected section %#v", cache)
+	}
+	assertStrEq(t, cache.Description, "The cache server.")
+
+	auth := root.Sections[2]
+	assertStrEq(t, auth.Prefix, "APP_MODULES_AUTH_")
+	if auth.MapEntry == nil || auth.MapEntry.Field != "Modules" || auth.MapEntry.Key != "auth" {
+		t.Errorf("Unexpected map entry %#v", auth.MapEntry)
+	}
+
+	placeholder := root.Sections[3]
+	assertStrEq(t, placeholder.Prefix, "APP_MODULES_<NAME>_")
+	assertStrEq(t, placeholder.Path, ".Modules[<NAME>: *stev_test.treeModule]")
+	if placeholder.MapEntry == nil || !placeholder.MapEntry.Placeholder {
+		t.Errorf("Unexpected map entry %#v", placeholder.MapEntry)
+	} else {
+		assertStrEq(t, placeholder.MapEntry.KeyFormat,
+			"<NAME> is the key of an entry of Modules in upper case, e.g., AUTH")
+	}
+	if len(placeholder.Fields) != 1 || !placeholder.Fields[0].Placeholder ||
+		placeholder.Fields[0].LookupKey != "APP_MODULES_<NAME>_ENABLED" {
+		t.Errorf("Unexpected fields %#v", placeholder.Fields)
+	}
+
+	embedded := root.Sections[4]
+	assertStrEq(t, embedded.Prefix, "APP_")
+	if !embedded.Squashed {
+		t.Errorf("Expected squashed section")
+	}
+
+	var paths []string
+	root.Walk(func(s *stev.DocsSection) bool {
+		paths = append(paths, s.Path)
+		return s.MapEntry == nil
+	})
+	if len(paths) != 6 {
+		t.Errorf("Unexpected paths %v", paths)
+	}
+}
+
+func TestDocsTreeEmptyMap(t *testing.T) {
+	fieldDocs, err := stev.Docs("", &treeConfig{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	var keys []string
+	for _, fd := range fieldDocs {
+		if fd.Placeholder {
+			keys = append(keys, fd.LookupKey)
+		}
+	}
+	if len(keys) != 1 || keys[0] != "MODULES_<NAME>_ENABLED" {
+		t.Errorf("Unexpected placeholder keys %v", keys)
+	}
+}
+
+type treeNode struct {
+	Name     string
+	Children map[string]*treeNode `env:",map"`
+}
+
+func TestDocsTreeRecursiveMap(t *testing.T) {
+	fieldDocs, err := stev.Docs("", &treeNode{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	var keys []string
+	for _, fd := range fieldDocs {
+		keys = append(keys, fd.LookupKey)
+	}
+	if len(keys) != 2 || keys[0] != "NAME" || keys[1] != "CHILDREN_<NAME>_NAME" {
+		t.Errorf("Unexpected keys: %v", keys)
+	}
+	if _, _, err = stev.DocsTree("", &treeNode{}); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+}
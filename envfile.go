@@ -0,0 +1,159 @@
+package stev
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// ReadEnvFile reads the file located at path and parses it with
+// ParseEnvFile.
+func ReadEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseEnvFile(f)
+}
+
+// ParseEnvFile parses the content of a dotenv-style file. Every
+// assignment is in the form of KEY=VALUE, optionally prefixed with
+// `export`. Values could be unquoted, single-quoted (literal) or
+// double-quoted (with backslash escapes). Quoted values could span
+// multiple lines. Lines starting with # are comments.
+func ParseEnvFile(r io.Reader) (map[string]string, error) {
+	fileEntries, err := ParseEnvFileEntries(r)
+	if err != nil {
+		return nil, err
+	}
+	entries := map[string]string{}
+	for _, e := range fileEntries {
+		entries[e.Key] = e.Value
+	}
+	return entries, nil
+}
+
+// EnvFileEntry is an assignment in a dotenv-style file.
+type EnvFileEntry struct {
+	Key   string
+	Value string
+
+	// The numbers, starting from 1, of the first and the last lines of
+	// the assignment. They differ if the value is quoted and spans
+	// multiple lines.
+	Line    int
+	EndLine int
+}
+
+// ParseEnvFileEntries is like ParseEnvFile but it returns the
+// assignments in the order they are in the file, including those
+// whose keys are assigned more than once.
+func ParseEnvFileEntries(r io.Reader) ([]EnvFileEntry, error) {
+	var entries []EnvFileEntry
+	sc := bufio.NewScanner(r)
+	// The values, e.g., inlined certificates, could be longer than the
+	// default limit of the line length.
+	sc.Buffer(nil, math.MaxInt)
+	lineNum := 0
+	for sc.Scan() {
+		lineNum++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		eq := strings.IndexByte(line, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid assignment on line %d", lineNum)
+		}
+		key := strings.TrimSpace(line[:eq])
+		rest := strings.TrimLeft(line[eq+1:], " \t")
+
+		if rest == "" || (rest[0] != '"' && rest[0] != '\'') {
+			entries = append(entries, EnvFileEntry{
+				Key: key, Value: trimInlineComment(rest),
+				Line: lineNum, EndLine: lineNum,
+			})
+			continue
+		}
+
+		quote := rest[0]
+		startLine := lineNum
+		raw := rest[1:]
+		for {
+			if end := closingQuoteIndex(raw, quote); end >= 0 {
+				raw = raw[:end]
+				break
+			}
+			if !sc.Scan() {
+				return nil, fmt.Errorf("unterminated quoted value on line %d", startLine)
+			}
+			lineNum++
+			raw += "\n" + sc.Text()
+		}
+		if quote == '"' {
+			raw = unescapeDoubleQuoted(raw)
+		}
+		entries = append(entries, EnvFileEntry{
+			Key: key, Value: raw,
+			Line: startLine, EndLine: lineNum,
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func trimInlineComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+			s = s[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(s)
+}
+
+func closingQuoteIndex(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		if quote == '"' && s[i] == '\\' {
+			i++
+			continue
+		}
+		if s[i] == quote {
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeDoubleQuoted(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '"', '\\', '$', '`':
+			sb.WriteByte(s[i])
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,63 @@
+package stev_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rez-go/stev"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	entries, err := stev.ParseEnvFile(strings.NewReader(`
+# comment
+NAME=Go # inline comment
+export QUOTED="hello \"world\"\nline"
+LITERAL='a \n b'
+MULTI="first
+second"
+EMPTY=
+`))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, entries["NAME"], "Go")
+	assertStrEq(t, entries["QUOTED"], "hello \"world\"\nline")
+	assertStrEq(t, entries["LITERAL"], `a \n b`)
+	assertStrEq(t, entries["MULTI"], "first\nsecond")
+	if v, ok := entries["EMPTY"]; !ok || v != "" {
+		t.Errorf("Unexpected value %q", v)
+	}
+}
+
+func TestParseEnvFileEntries(t *testing.T) {
+	entries, err := stev.ParseEnvFileEntries(strings.NewReader(`NAME=first
+# comment
+MULTI="first
+second"
+NAME=second
+`))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	expected := []stev.EnvFileEntry{
+		{Key: "NAME", Value: "first", Line: 1, EndLine: 1},
+		{Key: "MULTI", Value: "first\nsecond", Line: 3, EndLine: 4},
+		{Key: "NAME", Value: "second", Line: 5, EndLine: 5},
+	}
+	if !reflect.DeepEqual(entries, expected) {
+		t.Errorf("Unexpected entries: %#v", entries)
+	}
+}
+
+func TestParseEnvFileLongLine(t *testing.T) {
+	value := strings.Repeat("x", 100*1024)
+	entries, err := stev.ParseEnvFile(strings.NewReader("NAME=Go\nLONG=" + value + "\n"))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, entries["NAME"], "Go")
+	if entries["LONG"] != value {
+		t.Errorf("Expected a value of %d bytes, got %d", len(value), len(entries["LONG"]))
+	}
+}
@@ -0,0 +1,43 @@
+package stev
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LoadError holds all the errors encountered during a load when the
+// Loader is configured to collect errors.
+type LoadError struct {
+	Errors []error
+}
+
+func (e *LoadError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the collected errors so that they can be inspected
+// with errors.Is and errors.As.
+func (e *LoadError) Unwrap() []error {
+	return e.Errors
+}
+
+// FieldError is the error for a field whose value can't be loaded.
+type FieldError struct {
+	Field     string
+	Path      string
+	LookupKey string
+	Err       error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("unable to load field value (field %s key %s): %v",
+		e.Field, e.LookupKey, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
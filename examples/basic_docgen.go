@@ -30,6 +30,26 @@ func main() {
 		genConfigTemplate(prefix, cfg)
 		return
 	}
+	if len(os.Args) > 2 && os.Args[1] == "docs" {
+		genDocs(prefix, cfg, os.Args[2])
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "docs_sync" {
+		syncDocs(prefix, cfg, os.Args[2], len(os.Args) > 3 && os.Args[3] == "-check")
+		return
+	}
+	if len(os.Args) > 2 && os.Args[1] == "env_upgrade" {
+		err := docgen.UpdateEnvFile(os.Args[2], &cfg, docgen.WriteOptions{FieldPrefix: prefix})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "env_diff" {
+		os.Exit(docgen.RunDiff(os.Args[2:], os.Stdout, os.Stderr,
+			&cfg, docgen.WriteOptions{FieldPrefix: prefix}))
+	}
 
 	err := stev.LoadFromEnv(prefix, &cfg)
 	if err != nil {
@@ -48,9 +68,36 @@ func genConfigTemplate(prefix string, skeleton ServiceClientConfig) {
 	}
 }
 
+func genDocs(prefix string, skeleton ServiceClientConfig, format string) {
+	err := docgen.WriteDocs(os.Stdout, &skeleton, format, docgen.WriteOptions{
+		FieldPrefix: prefix,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// syncDocs updates the section of the file which holds the config
+// reference. With check set, it exits with non-zero status if the
+// section is stale.
+func syncDocs(prefix string, skeleton ServiceClientConfig, path string, check bool) {
+	opts := docgen.WriteOptions{FieldPrefix: prefix}
+	var err error
+	if check {
+		err = docgen.CheckFileSection(path, "", &skeleton, "markdown", opts)
+	} else {
+		err = docgen.UpdateFileSection(path, "", &skeleton, "markdown", opts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
 type ServiceClientCredentials struct {
 	ClientID     string `env:",required"`
-	ClientSecret string
+	ClientSecret string `env:",secret"`
 }
 
 func (ServiceClientCredentials) StevFieldDescriptions() map[string]string {
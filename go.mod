@@ -1,5 +1,5 @@
 module github.com/rez-go/stev
 
-go 1.15
+go 1.21
 
 require github.com/mitchellh/go-wordwrap v1.0.1
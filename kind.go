@@ -0,0 +1,129 @@
+package stev
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// FieldKind is the normalized kind of the values of a field. Unlike the
+// Go types, the kinds are stable identifiers which could be consumed by
+// other tools.
+type FieldKind string
+
+// Supported field kinds.
+const (
+	FieldKindString   FieldKind = "string"
+	FieldKindInteger  FieldKind = "integer"
+	FieldKindNumber   FieldKind = "number"
+	FieldKindBool     FieldKind = "bool"
+	FieldKindDuration FieldKind = "duration"
+	FieldKindList     FieldKind = "list"
+	FieldKindMap      FieldKind = "map"
+	FieldKindObject   FieldKind = "object"
+	FieldKindUnknown  FieldKind = "unknown"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// KindOf returns the normalized kind of the values of type t. Pointers
+// are resolved to the kind of their elements. Types which implement
+// encoding.TextUnmarshaler are strings in some format.
+func KindOf(t reflect.Type) FieldKind {
+	if t == nil {
+		return FieldKindUnknown
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return FieldKindDuration
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return FieldKindString
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return FieldKindBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return FieldKindInteger
+	case reflect.Float32, reflect.Float64:
+		return FieldKindNumber
+	case reflect.String:
+		return FieldKindString
+	case reflect.Slice, reflect.Array:
+		return FieldKindList
+	case reflect.Map:
+		return FieldKindMap
+	case reflect.Struct:
+		return FieldKindObject
+	}
+	return FieldKindUnknown
+}
+
+// isOpaqueType returns true if t is a struct, or a pointer to a struct,
+// which implements encoding.TextUnmarshaler. Such structs are treated
+// as single values.
+func isOpaqueType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct &&
+		reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// typeHintProvider is implemented by the types which describe the
+// format of their values for the docs.
+type typeHintProvider interface {
+	StevTypeHint() string
+}
+
+var typeHintProviderType = reflect.TypeOf((*typeHintProvider)(nil)).Elem()
+
+// TypeHintOf returns a description of the values accepted for type t,
+// to be read by the operators, e.g., "duration (e.g. 30s, 1h15m)". Types
+// could provide their own description by implementing the method
+// StevTypeHint() string. It returns an empty string for the types which
+// can't be loaded from a single value.
+func TypeHintOf(t reflect.Type) string {
+	if t == nil {
+		return ""
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(typeHintProviderType) {
+		return reflect.New(t).Interface().(typeHintProvider).StevTypeHint()
+	}
+	if t == durationType {
+		return "duration (e.g. 30s, 1h15m)"
+	}
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return "text in the format of " + t.String()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean (true/false/1/0; empty means true)"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if t.Bits() > 32 {
+			return "integer"
+		}
+		max := int64(1)<<(t.Bits()-1) - 1
+		return fmt.Sprintf("integer %d to %d", -max-1, max)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if t.Bits() > 32 {
+			return "non-negative integer"
+		}
+		return fmt.Sprintf("integer 0 to %d", uint64(1)<<t.Bits()-1)
+	case reflect.Float32, reflect.Float64:
+		return "number (e.g. 1.5, 2e3)"
+	case reflect.String:
+		return "string"
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package stev
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Load loads the values into a new instance of T using the default
+// Loader with opts applied. T is usually a struct type.
+//
+//	cfg, err := stev.Load[Config]("APP_")
+func Load[T any](prefix string, opts ...Option) (T, error) {
+	var skeleton T
+	return LoadInto(prefix, skeleton, opts...)
+}
+
+// LoadInto loads the values into a copy of skeleton, which could hold
+// the default values, using the default Loader with opts applied.
+//
+// Note that the copy is shallow; maps and pointers in skeleton are
+// shared with the result.
+func LoadInto[T any](prefix string, skeleton T, opts ...Option) (T, error) {
+	l, err := defaultLoader.with(opts...)
+	if err != nil {
+		return skeleton, fmt.Errorf("stev: %w", err)
+	}
+	if err = l.load(prefix, &skeleton); err != nil {
+		return skeleton, fmt.Errorf("stev: %w", err)
+	}
+	return skeleton, nil
+}
+
+// MustLoad is like Load but it collects all the errors and, if there's
+// any, prints them to stderr and exits the process with status 1.
+// It's intended to be called early in the main function.
+func MustLoad[T any](prefix string, opts ...Option) T {
+	var skeleton T
+	return MustLoadInto(prefix, skeleton, opts...)
+}
+
+// MustLoadInto is like LoadInto but it collects all the errors and, if
+// there's any, prints them to stderr and exits the process with
+// status 1.
+func MustLoadInto[T any](prefix string, skeleton T, opts ...Option) T {
+	// The caller's slice must not be written into.
+	opts = append(opts[:len(opts):len(opts)], WithCollectErrors(true))
+	cfg, err := LoadInto(prefix, skeleton, opts...)
+	if err != nil {
+		writeErrorReport(os.Stderr, err)
+		os.Exit(1)
+	}
+	return cfg
+}
+
+// writeErrorReport writes err in human-readable form, listing each
+// of the errors collected in a LoadError.
+func writeErrorReport(w io.Writer, err error) {
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		fmt.Fprintf(w, "configuration error: %v\n",
+			strings.TrimPrefix(err.Error(), "stev: "))
+		return
+	}
+	if len(loadErr.Errors) == 1 {
+		fmt.Fprintf(w, "configuration error: %v\n", loadErr.Errors[0])
+		return
+	}
+	fmt.Fprintf(w, "configuration has %d errors:\n", len(loadErr.Errors))
+	for _, e := range loadErr.Errors {
+		fmt.Fprintf(w, "  - %v\n", e)
+	}
+}
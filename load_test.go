@@ -0,0 +1,68 @@
+package stev_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rez-go/stev"
+)
+
+func TestLoadGeneric(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PFX_NAME", "Go")
+	os.Setenv("PFX_INNER_SIZE", "10")
+	cfg, err := stev.Load[OuterStruct]("PFX_")
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Name, "Go")
+	assertInt64Eq(t, cfg.Inner.Size, 10)
+}
+
+func TestLoadGenericPointer(t *testing.T) {
+	os.Clearenv()
+	cfg, err := stev.Load[*NameOnly]("")
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if cfg != nil {
+		t.Errorf("Expected nil, got %#v", cfg)
+	}
+	os.Setenv("NAME", "Go")
+	cfg, err = stev.Load[*NameOnly]("")
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Name, "Go")
+}
+
+func TestLoadGenericNonStruct(t *testing.T) {
+	os.Clearenv()
+	_, err := stev.Load[int]("")
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestLoadInto(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("COLOR", "RED")
+	cfg, err := stev.LoadInto("", InnerStruct{Color: "BLUE", Size: 3},
+		stev.WithSources(stev.MapSource{"SIZE": "7"}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Color, "BLUE")
+	assertInt64Eq(t, cfg.Size, 7)
+}
+
+func TestMustLoadIntoOptions(t *testing.T) {
+	os.Clearenv()
+	opts := make([]stev.Option, 1, 2)
+	opts[0] = stev.WithSources(stev.MapSource{"NAME": "Go"})
+	cfg := stev.MustLoadInto("", NameOnly{}, opts...)
+	assertStrEq(t, cfg.Name, "Go")
+	if opts[:2][1] != nil {
+		t.Errorf("Expected the spare capacity of the options to be untouched")
+	}
+}
@@ -0,0 +1,203 @@
+package stev
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal encodes the values of v into key-value pairs using the
+// default Loader. See Loader.Marshal.
+func Marshal(prefix string, v interface{}) (map[string]string, error) {
+	return defaultLoader.Marshal(prefix, v)
+}
+
+// Environ is like Marshal but returns the pairs as "KEY=value" strings,
+// sorted by the keys, in the form used by os.Environ and exec.Cmd.Env.
+func Environ(prefix string, v interface{}) ([]string, error) {
+	return defaultLoader.Environ(prefix, v)
+}
+
+// Marshal encodes the values of v into key-value pairs. It's the
+// inverse of LoadFromEnv; loading the result with the same prefix
+// reproduces the values of v.
+//
+// Fields with nil pointers, including nil pointer structs, are
+// omitted. Values of types which implement encoding.TextMarshaler are
+// encoded with their MarshalText method. A struct is encoded as a
+// single value only if it's loaded from one, i.e., it implements
+// encoding.TextUnmarshaler; otherwise its fields are encoded.
+func (l Loader) Marshal(prefix string, v interface{}) (map[string]string, error) {
+	l = l.withDefaults()
+	out := map[string]string{}
+	if err := l.marshal(prefix, reflect.ValueOf(v), out); err != nil {
+		return nil, fmt.Errorf("stev: %w", err)
+	}
+	return out, nil
+}
+
+// Environ is like Marshal but returns the pairs as "KEY=value" strings,
+// sorted by the keys.
+func (l Loader) Environ(prefix string, v interface{}) ([]string, error) {
+	entries, err := l.Marshal(prefix, v)
+	if err != nil {
+		return nil, err
+	}
+	environ := make([]string, 0, len(entries))
+	for k, v := range entries {
+		environ = append(environ, k+"="+v)
+	}
+	sort.Strings(environ)
+	return environ, nil
+}
+
+func (l Loader) marshal(
+	lookupPrefix string,
+	val reflect.Value,
+	out map[string]string,
+) error {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if !val.IsValid() {
+		return errors.New("requires struct value")
+	}
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("requires struct value, got %s", val.Type())
+	}
+
+	tType := val.Type()
+	for i := 0; i < tType.NumField(); i++ {
+		fInfo := tType.Field(i)
+		fVal := val.Field(i)
+		if fInfo.PkgPath != "" {
+			continue
+		}
+
+		fTagName, fTagOpts, ok, err := l.parseFieldTag(fInfo)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		fType := fInfo.Type
+		if fType.Kind() == reflect.Struct || (fType.Kind() == reflect.Ptr && fType.Elem().Kind() == reflect.Struct) {
+			if fType.Kind() == reflect.Ptr && fVal.IsNil() {
+				continue
+			}
+			// Same as the loader, which reads such a struct from a
+			// single value. It must implement encoding.TextMarshaler
+			// to be encoded.
+			if fTagName != "" && isOpaqueType(fType) {
+				lookupKey := l.fieldLookupKey(lookupPrefix, fTagName, fTagOpts)
+				strVal, err := l.encodeFieldValue(fVal)
+				if err != nil {
+					return fmt.Errorf("unable to encode field value (field %s key %s): %w",
+						fInfo.Name, lookupKey, err)
+				}
+				out[lookupKey] = strVal
+				continue
+			}
+			fieldPrefix := l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts)
+			if err = l.marshal(fieldPrefix, fVal, out); err != nil {
+				return fmt.Errorf("unable to encode field value (field %s key %s*): %w",
+					fInfo.Name, fieldPrefix, err)
+			}
+			continue
+		}
+
+		if fType.Kind() == reflect.Map && fTagOpts.Map {
+			if fType.Key().Kind() != reflect.String {
+				return fmt.Errorf("map requires an instance of map with string key (field %s)",
+					fInfo.Name)
+			}
+			fmBasePrefix := l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts)
+			for _, entryKey := range fVal.MapKeys() {
+				mapEntryKey := entryKey.String()
+				fmPrefix := fmBasePrefix + strings.ToUpper(mapEntryKey) + l.NamespaceSeparator
+				if err = l.marshal(fmPrefix, fVal.MapIndex(entryKey), out); err != nil {
+					return fmt.Errorf("map entry encoding failed: %w (field %s key %s)",
+						err, fInfo.Name, mapEntryKey)
+				}
+			}
+			continue
+		}
+
+		if fTagOpts.Squash {
+			return fmt.Errorf("squash can only be used to "+
+				"field which type is struct or pointer "+
+				"to struct (field %s)", fInfo.Name)
+		}
+
+		if fType.Kind() == reflect.Ptr && fVal.IsNil() {
+			continue
+		}
+		lookupKey := l.fieldLookupKey(lookupPrefix, fTagName, fTagOpts)
+		strVal, err := l.encodeFieldValue(fVal)
+		if err != nil {
+			return fmt.Errorf("unable to encode field value (field %s key %s): %w",
+				fInfo.Name, lookupKey, err)
+		}
+		out[lookupKey] = strVal
+	}
+	return nil
+}
+
+// encodeFieldValue is the inverse of loadFieldValue.
+func (l Loader) encodeFieldValue(fieldValue reflect.Value) (string, error) {
+	if fieldValue.Kind() == reflect.Ptr {
+		if fieldValue.IsNil() {
+			return "", nil
+		}
+		fieldValue = fieldValue.Elem()
+	}
+
+	if d, ok := fieldValue.Interface().(time.Duration); ok {
+		return d.String(), nil
+	}
+	if tm, ok := fieldValue.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	if fieldValue.CanAddr() {
+		if tm, ok := fieldValue.Addr().Interface().(encoding.TextMarshaler); ok {
+			b, err := tm.MarshalText()
+			return string(b), err
+		}
+	}
+
+	fieldType := fieldValue.Type()
+	switch fieldType.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(fieldValue.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fieldValue.Float(), 'g', -1, fieldType.Bits()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fieldValue.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fieldValue.Uint(), 10), nil
+	case reflect.String:
+		return fieldValue.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported field value type %q", fieldType.Name())
+	}
+}
+
+// formatFieldValue formats the value for the docs. It falls back to
+// the default formatting if the value can't be encoded.
+func (l Loader) formatFieldValue(fieldValue reflect.Value) string {
+	if s, err := l.encodeFieldValue(fieldValue); err == nil {
+		return s
+	}
+	return fmt.Sprintf("%v", fieldValue.Interface())
+}
@@ -0,0 +1,130 @@
+package stev_test
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rez-go/stev"
+)
+
+// HostPort is a struct which is encoded as a single value.
+type HostPort struct {
+	Host string
+	Port string
+}
+
+func (hp HostPort) MarshalText() ([]byte, error) {
+	return []byte(net.JoinHostPort(hp.Host, hp.Port)), nil
+}
+
+func (hp *HostPort) UnmarshalText(text []byte) error {
+	host, port, err := net.SplitHostPort(string(text))
+	if err != nil {
+		return err
+	}
+	hp.Host, hp.Port = host, port
+	return nil
+}
+
+// SemVer is a struct which is encoded as a single value but can't be
+// decoded from one. It's loaded from its fields.
+type SemVer struct {
+	Major int
+	Minor int
+}
+
+func (v SemVer) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%d", v.Major, v.Minor)), nil
+}
+
+type MarshalConfig struct {
+	Name        string
+	Count       int
+	Ratio       float64
+	Enabled     bool
+	Timeout     time.Duration
+	TimeoutPtr  *time.Duration
+	IP          net.IP
+	Listen      HostPort
+	Endpoint    *HostPort
+	Version     SemVer
+	Description string                 `env:"!ABSOLUTE_DESC"`
+	Nested      InnerPrefix            `env:"WITH"`
+	Optional    *AllOptional           `env:"PTR"`
+	Modules     map[string]interface{} `env:"MOD,map"`
+	InnerStruct
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	timeout := 90 * time.Second
+	src := MarshalConfig{
+		Name:        "Go",
+		Count:       -3,
+		Ratio:       1.5,
+		Enabled:     true,
+		Timeout:     time.Minute,
+		TimeoutPtr:  &timeout,
+		IP:          net.ParseIP("10.0.0.1"),
+		Listen:      HostPort{Host: "localhost", Port: "8080"},
+		Endpoint:    &HostPort{Host: "api.local", Port: "443"},
+		Version:     SemVer{Major: 1, Minor: 2},
+		Description: "has spaces = and #",
+		Nested:      InnerPrefix{Color: "RED", Size: 9001},
+		Modules: map[string]interface{}{
+			"auth": &AuthModuleConfig{ClientID: "root"},
+		},
+		InnerStruct: InnerStruct{Color: "BLUE", Strength: 7},
+	}
+
+	entries, err := stev.Marshal("PFX_", &src)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, entries["PFX_TIMEOUT"], "1m0s")
+	assertStrEq(t, entries["PFX_LISTEN"], "localhost:8080")
+	assertStrEq(t, entries["PFX_ENDPOINT"], "api.local:443")
+	assertStrEq(t, entries["PFX_VERSION_MINOR"], "2")
+	assertStrEq(t, entries["ABSOLUTE_DESC"], "has spaces = and #")
+	assertStrEq(t, entries["PFX_MOD_AUTH_CLIENT_ID"], "root")
+	assertStrEq(t, entries["PFX_COLOR"], "BLUE")
+	if _, ok := entries["PFX_PTR_NAME"]; ok {
+		t.Errorf("Nil pointer struct must be omitted")
+	}
+
+	os.Clearenv()
+	for k, v := range entries {
+		os.Setenv(k, v)
+	}
+	dst := MarshalConfig{
+		Modules: map[string]interface{}{
+			"auth": &AuthModuleConfig{},
+		},
+	}
+	if err = stev.LoadFromEnv("PFX_", &dst); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if !reflect.DeepEqual(src, dst) {
+		t.Errorf("Round-trip failed:\n\twanted: %#v\n\thave:   %#v", src, dst)
+	}
+}
+
+func TestMarshalOpaqueWithoutMarshalText(t *testing.T) {
+	_, err := stev.Marshal("", &OpaqueConfig{Database: DSN{Host: "db", Name: "app"}})
+	if err == nil || !strings.Contains(err.Error(), "key DATABASE") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestEnviron(t *testing.T) {
+	environ, err := stev.Environ("", InnerStruct{Color: "RED", Size: 2})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, strings.Join(environ, " "),
+		"ASPECT_RATIO=0 COLOR=RED SIZE=2 STRENGTH=0")
+}
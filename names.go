@@ -0,0 +1,152 @@
+package stev
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NameConverter converts the names of struct fields into the names used
+// to construct the lookup keys.
+type NameConverter interface {
+	ConvertFieldName(fieldName string) string
+}
+
+// ScreamingSnakeCase returns a NameConverter which converts field names
+// into SCREAMING_SNAKE_CASE, e.g., ServerURL into SERVER_URL. This is
+// the default converter.
+//
+// The acronyms, e.g., OAuth, IPv6 or gRPC, are treated as single words
+// when they are found in the field names with the exact casing.
+func ScreamingSnakeCase(acronyms ...string) NameConverter {
+	return SnakeCaseConverter{Acronyms: acronyms}
+}
+
+// LowerSnakeCase returns a NameConverter which converts field names
+// into lower snake_case, e.g., ServerURL into server_url. See
+// ScreamingSnakeCase for the acronyms.
+func LowerSnakeCase(acronyms ...string) NameConverter {
+	return SnakeCaseConverter{Lower: true, Acronyms: acronyms}
+}
+
+// Verbatim returns a NameConverter which uses the field names as they
+// are.
+func Verbatim() NameConverter {
+	return verbatimConverter{}
+}
+
+// SnakeCaseConverter converts field names into snake_case words.
+type SnakeCaseConverter struct {
+	// By default, the result is in upper case. Set this to true to
+	// get the result in lower case.
+	Lower bool
+
+	// Words which must not be split. They are matched case-sensitively.
+	Acronyms []string
+}
+
+var _ NameConverter = SnakeCaseConverter{}
+
+// ConvertFieldName converts fieldName into snake_case.
+func (c SnakeCaseConverter) ConvertFieldName(fieldName string) string {
+	var words []string
+	var run strings.Builder
+	flushRun := func() {
+		if run.Len() > 0 {
+			words = append(words, convertToScreamingSnakeCase(run.String()))
+			run.Reset()
+		}
+	}
+
+	for i := 0; i < len(fieldName); {
+		if acronym := c.matchAcronym(fieldName, i); acronym != "" {
+			flushRun()
+			words = append(words, strings.ToUpper(acronym))
+			i += len(acronym)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(fieldName[i:])
+		run.WriteRune(r)
+		i += size
+	}
+	flushRun()
+
+	name := strings.Join(words, "_")
+	if c.Lower {
+		return strings.ToLower(name)
+	}
+	return name
+}
+
+// matchAcronym returns the longest acronym found at the position i of
+// fieldName which is not a part of a longer word.
+func (c SnakeCaseConverter) matchAcronym(fieldName string, i int) string {
+	var longest string
+	for _, acronym := range c.Acronyms {
+		if len(acronym) <= len(longest) ||
+			!strings.HasPrefix(fieldName[i:], acronym) {
+			continue
+		}
+		// An acronym which starts in lower case, e.g., gRPC, must
+		// not be found in the middle of a word.
+		if i > 0 {
+			first, _ := utf8.DecodeRuneInString(acronym)
+			prev, _ := utf8.DecodeLastRuneInString(fieldName[:i])
+			if !unicode.IsUpper(first) && unicode.IsLetter(prev) {
+				continue
+			}
+		}
+		if end := i + len(acronym); end < len(fieldName) {
+			next, _ := utf8.DecodeRuneInString(fieldName[end:])
+			if unicode.IsLower(next) {
+				continue
+			}
+		}
+		longest = acronym
+	}
+	return longest
+}
+
+type verbatimConverter struct{}
+
+func (verbatimConverter) ConvertFieldName(fieldName string) string {
+	return fieldName
+}
+
+func (l Loader) convertFieldName(fieldName string) string {
+	if l.NameConverter != nil {
+		return l.NameConverter.ConvertFieldName(fieldName)
+	}
+	return convertToScreamingSnakeCase(fieldName)
+}
+
+func convertToScreamingSnakeCase(fieldName string) string {
+	if fieldName == "" {
+		return ""
+	}
+	var outRunes []rune
+	prevIsUpper := true
+	for _, r := range fieldName {
+		if unicode.IsUpper(r) || unicode.IsDigit(r) {
+			if prevIsUpper {
+				outRunes = append(outRunes, r)
+				continue
+			}
+			outRunes = append(outRunes, '_', r)
+			prevIsUpper = true
+		} else {
+			if prevIsUpper && len(outRunes) >= 2 {
+				cR := outRunes[len(outRunes)-2]
+				if unicode.IsUpper(cR) || unicode.IsDigit(cR) {
+					tR := outRunes[len(outRunes)-1]
+					outRunes[len(outRunes)-1] = '_'
+					outRunes = append(outRunes, tR)
+				}
+			}
+			outRunes = append(outRunes, r)
+			prevIsUpper = false
+		}
+	}
+	tagName := strings.ToUpper(string(outRunes))
+	return tagName
+}
@@ -0,0 +1,61 @@
+package stev_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rez-go/stev"
+)
+
+func TestNameConverters(t *testing.T) {
+	cases := []struct {
+		conv   stev.NameConverter
+		input  string
+		output string
+	}{
+		{stev.ScreamingSnakeCase(), "ServerURL", "SERVER_URL"},
+		{stev.ScreamingSnakeCase(), "IPV4Address", "IPV4_ADDRESS"},
+		{stev.ScreamingSnakeCase(), "IPv6Addr", "I_PV_6_ADDR"},
+		{stev.ScreamingSnakeCase("IPv6"), "IPv6Addr", "IPV6_ADDR"},
+		{stev.ScreamingSnakeCase("OAuth", "OAuth2"), "OAuth2Token", "OAUTH2_TOKEN"},
+		{stev.ScreamingSnakeCase("OAuth"), "GitHubOAuthToken", "GIT_HUB_OAUTH_TOKEN"},
+		{stev.ScreamingSnakeCase("gRPC"), "gRPCPort", "GRPC_PORT"},
+		{stev.ScreamingSnakeCase("gRPC"), "DebugRPCPort", "DEBUG_RPC_PORT"},
+		{stev.ScreamingSnakeCase("ID"), "IDentity", "I_DENTITY"},
+		{stev.LowerSnakeCase("OAuth"), "OAuthClientID", "oauth_client_id"},
+		{stev.Verbatim(), "ServerURL", "ServerURL"},
+	}
+	for _, c := range cases {
+		assertStrEq(t, c.conv.ConvertFieldName(c.input), c.output)
+	}
+
+	// Whether the acronym is matched after a lowercase letter is
+	// ambiguous, e.g., USEG_RPC_PORT or USE_GRPC_PORT; only the letters
+	// and the trailing words are pinned.
+	key := stev.ScreamingSnakeCase("gRPC").ConvertFieldName("UsegRPCPort")
+	if strings.ReplaceAll(key, "_", "") != "USEGRPCPORT" || !strings.HasSuffix(key, "RPC_PORT") {
+		t.Errorf("Unexpected key %q", key)
+	}
+}
+
+func TestLoaderNameConverter(t *testing.T) {
+	l, err := stev.NewLoader(
+		stev.WithNameConverter(stev.LowerSnakeCase("OAuth")),
+		stev.WithSources(stev.MapSource{"app_oauth_token": "secret"}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	cfg := struct {
+		OAuthToken string
+	}{}
+	if err = l.LoadFromEnv("app_", &cfg); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.OAuthToken, "secret")
+
+	fieldDocs, err := l.Docs("app_", &cfg)
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, fieldDocs[0].LookupKey, "app_oauth_token")
+}
@@ -0,0 +1,160 @@
+package stev
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Option configures a Loader. See NewLoader.
+type Option func(*Loader)
+
+// WithStructFieldTagKey sets the key of the struct field tag the loader
+// processes.
+func WithStructFieldTagKey(key string) Option {
+	return func(l *Loader) { l.StructFieldTagKey = key }
+}
+
+// WithDocsTagKey sets the key of the struct field tag which holds the
+// docs of the fields.
+func WithDocsTagKey(key string) Option {
+	return func(l *Loader) { l.DocsTagKey = key }
+}
+
+// WithNamespaceSeparator sets the string used to join the prefixes and
+// the names of the fields.
+func WithNamespaceSeparator(sep string) Option {
+	return func(l *Loader) { l.NamespaceSeparator = sep }
+}
+
+// WithIgnoredStructFieldName sets the tag name which marks the fields
+// to be ignored.
+func WithIgnoredStructFieldName(name string) Option {
+	return func(l *Loader) { l.IgnoredStructFieldName = name }
+}
+
+// WithSquashStructFieldName sets the tag name which marks the struct
+// fields to be treated as embedded.
+func WithSquashStructFieldName(name string) Option {
+	return func(l *Loader) { l.SquashStructFieldName = name }
+}
+
+// WithSources sets the sources to look up the values from, in the order
+// of precedence.
+func WithSources(sources ...Source) Option {
+	return func(l *Loader) { l.Sources = sources }
+}
+
+// WithNameConverter sets the converter for the names of the fields
+// which don't have their name specified in the tag.
+func WithNameConverter(c NameConverter) Option {
+	return func(l *Loader) { l.NameConverter = c }
+}
+
+// WithStrict enables or disables the strict mode.
+func WithStrict(strict bool) Option {
+	return func(l *Loader) { l.Strict = strict }
+}
+
+// WithCollectErrors enables or disables the error-collection mode.
+func WithCollectErrors(collect bool) Option {
+	return func(l *Loader) { l.CollectErrors = collect }
+}
+
+// WithBestEffort enables the best-effort mode. The fields whose values
+// can't be parsed are skipped and passed to onFieldError, which could
+// be nil. A skipped field which is required is treated as missing.
+func WithBestEffort(onFieldError func(err *FieldError)) Option {
+	return func(l *Loader) {
+		l.BestEffort = true
+		l.OnFieldError = onFieldError
+	}
+}
+
+// WithNoOverride enables or disables the no-override mode.
+func WithNoOverride(noOverride bool) Option {
+	return func(l *Loader) { l.NoOverride = noOverride }
+}
+
+// WithIgnoreUntagged enables or disables loading of the fields which
+// don't have the tag.
+func WithIgnoreUntagged(ignoreUntagged bool) Option {
+	return func(l *Loader) { l.IgnoreUntagged = ignoreUntagged }
+}
+
+// NewLoader creates a Loader configured with opts. Settings which are not
+// provided are set to their defaults.
+func NewLoader(opts ...Option) (*Loader, error) {
+	l, err := Loader{}.with(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("stev: %w", err)
+	}
+	return &l, nil
+}
+
+// with returns a validated copy of the loader with opts applied.
+func (l Loader) with(opts ...Option) (Loader, error) {
+	for _, opt := range opts {
+		opt(&l)
+	}
+	l = l.withDefaults()
+	if err := l.validate(); err != nil {
+		return Loader{}, err
+	}
+	return l, nil
+}
+
+// SetDefault replaces the default Loader, the one used by the
+// package-level functions, e.g., LoadFromEnv and Docs. Passing nil
+// restores the built-in default.
+func SetDefault(l *Loader) {
+	if l == nil {
+		defaultLoader = Loader{}.withDefaults()
+		return
+	}
+	defaultLoader = l.withDefaults()
+}
+
+// withDefaults returns a copy of the loader with the settings which are
+// not set assigned with their respective defaults.
+func (l Loader) withDefaults() Loader {
+	if l.StructFieldTagKey == "" {
+		l.StructFieldTagKey = StructFieldTagKeyDefault
+	}
+	if l.DocsTagKey == "" {
+		l.DocsTagKey = DocsTagKeyDefault
+	}
+	if l.NamespaceSeparator == "" {
+		l.NamespaceSeparator = NamespaceSeparatorDefault
+	}
+	if l.IgnoredStructFieldName == "" {
+		l.IgnoredStructFieldName = IgnoredStructFieldNameDefault
+	}
+	if l.SquashStructFieldName == "" {
+		l.SquashStructFieldName = SquashStructFieldNameDefault
+	}
+	return l
+}
+
+func (l Loader) validate() error {
+	if strings.ContainsAny(l.StructFieldTagKey, " \t:\"`") {
+		return fmt.Errorf("invalid struct field tag key %q", l.StructFieldTagKey)
+	}
+	if strings.ContainsAny(l.DocsTagKey, " \t:\"`") || l.DocsTagKey == l.StructFieldTagKey {
+		return fmt.Errorf("invalid docs tag key %q", l.DocsTagKey)
+	}
+	if l.IgnoredStructFieldName == l.SquashStructFieldName {
+		return errors.New("ignored and squash struct field names must be different")
+	}
+	for _, name := range []string{l.IgnoredStructFieldName, l.SquashStructFieldName} {
+		if strings.HasPrefix(name, "!") || strings.Contains(name, ",") {
+			return fmt.Errorf("invalid struct field name marker %q", name)
+		}
+	}
+	for i, src := range l.Sources {
+		if src == nil {
+			return fmt.Errorf("source %d is nil", i)
+		}
+	}
+	return nil
+}
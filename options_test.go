@@ -0,0 +1,240 @@
+package stev_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rez-go/stev"
+)
+
+func TestNewLoaderDefaults(t *testing.T) {
+	os.Clearenv()
+	l, err := stev.NewLoader()
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, l.StructFieldTagKey, stev.StructFieldTagKeyDefault)
+	assertStrEq(t, l.NamespaceSeparator, stev.NamespaceSeparatorDefault)
+	assertStrEq(t, l.IgnoredStructFieldName, stev.IgnoredStructFieldNameDefault)
+	assertStrEq(t, l.SquashStructFieldName, stev.SquashStructFieldNameDefault)
+}
+
+func TestNewLoaderInvalid(t *testing.T) {
+	_, err := stev.NewLoader(
+		stev.WithIgnoredStructFieldName("x"),
+		stev.WithSquashStructFieldName("x"))
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestZeroLoader(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INNER_COLOR", "RED")
+	cfg := OuterStruct{}
+	err := stev.Loader{}.LoadFromEnv("", &cfg)
+	if err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Inner.Color, "RED")
+}
+
+func TestLoaderOptions(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("APP.NAME", "from env")
+	l, err := stev.NewLoader(
+		stev.WithStructFieldTagKey("cfg"),
+		stev.WithNamespaceSeparator("."),
+		stev.WithSources(stev.MapSource{
+			"APP.NAME":        "Go",
+			"APP.INNER.COLOR": "RED",
+		}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	cfg := struct {
+		Name  string `cfg:"NAME"`
+		Inner struct {
+			Color string
+		} `cfg:"INNER"`
+	}{}
+	err = l.LoadFromEnv("APP.", &cfg)
+	if err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Name, "Go")
+	assertStrEq(t, cfg.Inner.Color, "RED")
+}
+
+func TestLoaderCollectErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INNER_SIZE", "big")
+	os.Setenv("INNER_STRENGTH", "-1")
+	os.Setenv("INNER_COLOR", "RED")
+	l, err := stev.NewLoader(stev.WithCollectErrors(true))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	cfg := OuterStruct{}
+	err = l.LoadFromEnv("", &cfg)
+	var loadErr *stev.LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Expected LoadError, got %#v", err)
+	}
+	if len(loadErr.Errors) != 2 {
+		t.Errorf("Expected 2 errors, got %v", loadErr.Errors)
+	}
+	assertStrEq(t, cfg.Inner.Color, "RED")
+}
+
+func TestLoaderStrict(t *testing.T) {
+	os.Clearenv()
+	l, err := stev.NewLoader(stev.WithStrict(true))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	err = l.LoadFromEnv("", &struct {
+		Name string `env:",requird"`
+	}{})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+	err = l.LoadFromEnv("", &struct {
+		Name  string
+		Other string `env:"NAME"`
+	}{})
+	if err == nil {
+		t.Errorf("Expected error")
+	}
+}
+
+func TestLoaderUnknownTagOption(t *testing.T) {
+	os.Clearenv()
+	type config struct {
+		Name string `env:",bogus,required"`
+	}
+	err := stev.LoadFromEnv("", &config{})
+	if err == nil || !strings.Contains(err.Error(), "field is required") {
+		t.Errorf("Expected required error, got %v", err)
+	}
+
+	l, err := stev.NewLoader(stev.WithStrict(true))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	err = l.LoadFromEnv("", &config{})
+	if err == nil || !strings.Contains(err.Error(), `unknown tag option "bogus"`) {
+		t.Errorf("Expected unknown option error, got %v", err)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	os.Clearenv()
+	defer stev.SetDefault(nil)
+	l, err := stev.NewLoader(stev.WithSources(stev.MapSource{"NAME": "Go"}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	stev.SetDefault(l)
+	cfg := NameOnly{}
+	if err = stev.LoadFromEnv("", &cfg); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Name, "Go")
+}
+
+func TestLoaderBestEffort(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INNER_SIZE", "big")
+	os.Setenv("INNER_COLOR", "RED")
+	var skipped []*stev.FieldError
+	l, err := stev.NewLoader(stev.WithBestEffort(func(err *stev.FieldError) {
+		skipped = append(skipped, err)
+	}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	cfg := OuterStruct{}
+	if err = l.LoadFromEnv("", &cfg); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Inner.Color, "RED")
+	if len(skipped) != 1 {
+		t.Fatalf("Expected 1 skipped field, got %d", len(skipped))
+	}
+	assertStrEq(t, skipped[0].LookupKey, "INNER_SIZE")
+	assertStrEq(t, skipped[0].Path, ".Inner.Size")
+}
+
+func TestLoaderBestEffortRequired(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PORT", "http")
+	var skipped []*stev.FieldError
+	l, err := stev.NewLoader(stev.WithBestEffort(func(err *stev.FieldError) {
+		skipped = append(skipped, err)
+	}))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	var cfg struct {
+		Port int32 `env:",required"`
+	}
+	err = l.LoadFromEnv("", &cfg)
+	if err == nil || !strings.Contains(err.Error(), "field is required (field Port key PORT)") {
+		t.Errorf("Expected the required error, got %#v", err)
+	}
+	if len(skipped) != 1 {
+		t.Fatalf("Expected 1 skipped field, got %d", len(skipped))
+	}
+	assertStrEq(t, skipped[0].LookupKey, "PORT")
+}
+
+func TestLoaderNoOverride(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NAME", "from env")
+	os.Setenv("INNER_COLOR", "RED")
+	os.Setenv("INNER_SIZE", "10")
+	l, err := stev.NewLoader(stev.WithNoOverride(true))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	cfg := OuterStruct{Name: "preset", Inner: InnerStruct{Size: 5}}
+	if err = l.LoadFromEnv("", &cfg); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Name, "preset")
+	assertStrEq(t, cfg.Inner.Color, "RED")
+	assertInt64Eq(t, cfg.Inner.Size, 5)
+
+	reqCfg := RequiredName{Name: "preset"}
+	if err = l.LoadFromEnv("", &reqCfg); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+}
+
+func TestLoaderIgnoreUntagged(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NAME", "Go")
+	os.Setenv("COLOR", "RED")
+	os.Setenv("ID", "42")
+	l, err := stev.NewLoader(stev.WithIgnoreUntagged(true))
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	type Domain struct {
+		ID    string
+		Color string `env:"COLOR"`
+	}
+	cfg := struct {
+		Domain
+		Name string
+	}{}
+	if err = l.LoadFromEnv("", &cfg); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Name, "")
+	assertStrEq(t, cfg.ID, "")
+	assertStrEq(t, cfg.Color, "RED")
+}
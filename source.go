@@ -0,0 +1,64 @@
+package stev
+
+import "os"
+
+// Source provides the values to be loaded by a Loader.
+type Source interface {
+	// LookupEnv returns the value of the key and whether the key is
+	// present in the source.
+	LookupEnv(key string) (value string, ok bool)
+}
+
+// LookupFuncSource adapts a lookup function, e.g., os.LookupEnv, into
+// a Source.
+type LookupFuncSource EnvLookupFunc
+
+// LookupEnv calls the function.
+func (fn LookupFuncSource) LookupEnv(key string) (string, bool) {
+	return fn(key)
+}
+
+// EnvSource returns a Source which looks up the values from the process
+// environment.
+func EnvSource() Source {
+	return LookupFuncSource(os.LookupEnv)
+}
+
+// MapSource is a Source backed by a map.
+type MapSource map[string]string
+
+// LookupEnv looks up the key in the map.
+func (m MapSource) LookupEnv(key string) (string, bool) {
+	v, ok := m[key]
+	return v, ok
+}
+
+// FileSource reads the dotenv-style file located at path and returns
+// its entries as a Source. See ParseEnvFile for the format.
+func FileSource(path string) (Source, error) {
+	entries, err := ReadEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return MapSource(entries), nil
+}
+
+// LookupEnv looks up the key from the sources of the default Loader.
+func LookupEnv(key string) (string, bool) {
+	return defaultLoader.LookupEnv(key)
+}
+
+// LookupEnv looks up the key from the sources of the loader, in the
+// order of precedence. A Loader without sources looks up the process
+// environment. This makes a Loader a Source itself.
+func (l Loader) LookupEnv(key string) (string, bool) {
+	if len(l.Sources) == 0 {
+		return os.LookupEnv(key)
+	}
+	for _, src := range l.Sources {
+		if v, ok := src.LookupEnv(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
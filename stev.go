@@ -1,14 +1,14 @@
 package stev
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
-	"os"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-	"unicode"
 )
 
 // LoadFromEnv loads the values and put them into target using default Loader.
@@ -21,36 +21,91 @@ func LoadFromEnv(prefix string, target interface{}) error {
 // Deprecated: Please use LoadFromEnv instead.
 var LoadEnv = LoadFromEnv
 
+// Docs returns the documentation of the fields of structure using
+// default Loader.
 func Docs(prefix string, structure interface{}) ([]FieldDocs, error) {
-	l := defaultLoader
-	fieldDocs := []FieldDocs{}
-	_, err := l.loadFromEnv(prefix, structure, false, false, "", &fieldDocs)
-	if err != nil {
-		return nil, err
-	}
-	return fieldDocs, nil
+	return defaultLoader.Docs(prefix, structure)
+}
+
+// AllDocs is like Docs but it includes the fields with the docs_hidden
+// tag option.
+func AllDocs(prefix string, structure interface{}) ([]FieldDocs, error) {
+	return defaultLoader.AllDocs(prefix, structure)
+}
+
+// DocsTree returns the documentation of structure as a tree of
+// sections, along with the list of its fields, using default Loader.
+func DocsTree(prefix string, structure interface{}) (*DocsSection, []FieldDocs, error) {
+	return defaultLoader.DocsTree(prefix, structure)
+}
+
+// AllDocsTree is like DocsTree but it includes the fields with the
+// docs_hidden tag option.
+func AllDocsTree(prefix string, structure interface{}) (*DocsSection, []FieldDocs, error) {
+	return defaultLoader.AllDocsTree(prefix, structure)
 }
 
 // EnvLookupFunc is a function signature which can be satisfied by os.LookupEnv.
 type EnvLookupFunc = func(key string) (value string, ok bool)
 
-// Loader is [TBD]
-//
-// TODO: config: field error ignore (best effort), no override,
-// tag name conversion (e.g., from FieldName to FIELD_NAME), ignore untagged
+// Loader loads values into structs. The zero value is usable; settings
+// which are not set will use their respective defaults. Use NewLoader
+// to create a validated instance.
 type Loader struct {
 	StructFieldTagKey      string
+	DocsTagKey             string
 	NamespaceSeparator     string
 	IgnoredStructFieldName string
 	SquashStructFieldName  string
 
-	lookupEnv EnvLookupFunc
+	// Sources to look up the values from, in the order of precedence.
+	// If it's empty, the values are looked up from the process
+	// environment.
+	Sources []Source
+
+	// NameConverter converts the names of the fields which don't have
+	// their name specified in the tag. If it's nil, ScreamingSnakeCase
+	// without acronyms is used.
+	NameConverter NameConverter
+
+	// In strict mode, unknown tag options and fields which resolve to
+	// the same key are treated as errors.
+	Strict bool
+
+	// If set to true, the loader will keep loading the rest of the
+	// fields when it encounters an error. All the errors will be
+	// returned as a *LoadError.
+	CollectErrors bool
+
+	// In best-effort mode, the fields whose values can't be parsed are
+	// skipped and reported to OnFieldError instead of failing the load.
+	// A skipped field which is required is treated as missing.
+	BestEffort bool
+
+	// OnFieldError receives the fields skipped in best-effort mode.
+	OnFieldError func(err *FieldError)
+
+	// If set to true, the fields which already have non-zero values in
+	// the target are kept as they are. Such fields also satisfy the
+	// required constraint.
+	NoOverride bool
+
+	// If set to true, only the fields which have the tag are loaded.
+	// Embedded structs without the tag are still descended into.
+	IgnoreUntagged bool
+
+	// Docs registered with RegisterDocs.
+	typeDocs *typeDocsRegistry
 }
 
 // StructFieldTagKeyDefault is the string we use to identify the struct field tag
 // we must process.
 const StructFieldTagKeyDefault = "env"
 
+// DocsTagKeyDefault is the key of the struct field tag which holds the
+// docs of the field. See FieldDocsDescriptor for an alternative.
+const DocsTagKeyDefault = "envdoc"
+
 // NamespaceSeparatorDefault is [TBD].
 const NamespaceSeparatorDefault = "_"
 
@@ -65,6 +120,7 @@ const SquashStructFieldNameDefault = "&"
 
 var defaultLoader = Loader{
 	StructFieldTagKey:      StructFieldTagKeyDefault,
+	DocsTagKey:             DocsTagKeyDefault,
 	NamespaceSeparator:     NamespaceSeparatorDefault,
 	IgnoredStructFieldName: IgnoredStructFieldNameDefault,
 	SquashStructFieldName:  SquashStructFieldNameDefault,
@@ -72,13 +128,76 @@ var defaultLoader = Loader{
 
 // LoadFromEnv loads values into target from environment variables.
 func (l Loader) LoadFromEnv(prefix string, target interface{}) error {
-	_, err := l.loadFromEnv(prefix, target, false, false, "", nil)
-	if err != nil {
+	if err := l.withDefaults().load(prefix, target); err != nil {
 		return fmt.Errorf("stev: %w", err)
 	}
 	return nil
 }
 
+func (l Loader) load(prefix string, target interface{}) error {
+	st := l.newLoadState(nil)
+	_, err := l.loadFromEnv(prefix, target, false, false, "", st)
+	if err == nil && len(st.errs) > 0 {
+		err = &LoadError{Errors: st.errs}
+	}
+	return err
+}
+
+// Docs returns the documentation of the fields of structure. Fields
+// with the docs_hidden tag option are excluded.
+func (l Loader) Docs(prefix string, structure interface{}) ([]FieldDocs, error) {
+	return l.docs(prefix, structure, false)
+}
+
+// AllDocs is like Docs but it includes the fields with the docs_hidden
+// tag option. Those fields are flagged with Hidden.
+func (l Loader) AllDocs(prefix string, structure interface{}) ([]FieldDocs, error) {
+	return l.docs(prefix, structure, true)
+}
+
+// DocsTree returns the documentation of structure as a tree of
+// sections, one for each struct. The fields are also returned as the
+// list returned by Docs, both being collected in a single pass. Fields
+// with the docs_hidden tag option are excluded.
+func (l Loader) DocsTree(prefix string, structure interface{}) (*DocsSection, []FieldDocs, error) {
+	return l.docsTree(prefix, structure, false)
+}
+
+// AllDocsTree is like DocsTree but it includes the fields with the
+// docs_hidden tag option.
+func (l Loader) AllDocsTree(prefix string, structure interface{}) (*DocsSection, []FieldDocs, error) {
+	return l.docsTree(prefix, structure, true)
+}
+
+func (l Loader) docs(
+	prefix string, structure interface{}, includeHidden bool,
+) ([]FieldDocs, error) {
+	_, fieldDocs, err := l.docsTree(prefix, structure, includeHidden)
+	return fieldDocs, err
+}
+
+func (l Loader) docsTree(
+	prefix string, structure interface{}, includeHidden bool,
+) (*DocsSection, []FieldDocs, error) {
+	l = l.withDefaults()
+	fieldDocs := []FieldDocs{}
+	st := l.newLoadState(&fieldDocs)
+	st.includeHidden = includeHidden
+	st.section = &DocsSection{
+		Prefix: prefix,
+		Type:   structType(reflect.TypeOf(structure)),
+		Self:   l.selfDocsDescriptor(structure),
+	}
+	_, err := l.loadFromEnv(prefix, structure, false, false, "", st)
+	if err == nil && len(st.errs) > 0 {
+		err = &LoadError{Errors: st.errs}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return st.section, fieldDocs, nil
+}
+
 // LoadEnv loads values into target from environment variables.
 //
 // Deprecated: Use LoadFromEnv.
@@ -91,21 +210,76 @@ type fieldKey struct {
 	lookupKey string
 }
 
+// loadState holds the state shared by the recursive calls of a single
+// load.
+type loadState struct {
+	fieldDocs     *[]FieldDocs
+	includeHidden bool
+
+	// The section the fields being documented belong to.
+	section *DocsSection
+
+	// The number of map-entry placeholders the fields being documented
+	// are in.
+	placeholderDepth int
+	// The entry types of the map-entry placeholders being documented.
+	placeholderTypes map[reflect.Type]bool
+
+	collectErrors bool
+	errs          []error
+
+	// Lookup keys to the paths of the fields which use them. Only
+	// tracked in strict mode.
+	keys map[string]string
+}
+
+func (l Loader) newLoadState(fieldDocs *[]FieldDocs) *loadState {
+	st := &loadState{
+		fieldDocs:     fieldDocs,
+		collectErrors: l.CollectErrors,
+	}
+	if l.Strict {
+		st.keys = map[string]string{}
+	}
+	return st
+}
+
+// fail records err if the errors are to be collected. Otherwise,
+// it returns err back to be returned by the caller.
+func (st *loadState) fail(err error) error {
+	if st.collectErrors {
+		st.errs = append(st.errs, err)
+		return nil
+	}
+	return err
+}
+
+// claimKey registers the key used by the field at fieldPath. It returns
+// an error if the key has been used by another field. Only applies in
+// strict mode.
+func (st *loadState) claimKey(lookupKey, fieldPath string) error {
+	if st.keys == nil {
+		return nil
+	}
+	if otherPath, exists := st.keys[lookupKey]; exists && otherPath != fieldPath {
+		return fmt.Errorf("duplicate key %s (fields %s and %s)",
+			lookupKey, otherPath, fieldPath)
+	}
+	st.keys[lookupKey] = fieldPath
+	return nil
+}
+
 func (l Loader) loadFromEnv(
 	lookupPrefix string,
 	target interface{},
 	parentIsRequired bool,
 	reqCancel bool,
 	fieldPath string,
-	fieldDocs *[]FieldDocs,
+	st *loadState,
 ) (loadedAny bool, err error) {
-	lookupEnv := l.lookupEnv
-	if lookupEnv == nil {
-		lookupEnv = os.LookupEnv
-	}
+	fieldDocs := st.fieldDocs
 	docsMode := fieldDocs != nil
 
-	tagName := l.StructFieldTagKey
 	nsSep := l.NamespaceSeparator
 
 	tVal := reflect.ValueOf(target)
@@ -119,17 +293,20 @@ func (l Loader) loadFromEnv(
 
 	tVal = tVal.Elem()
 	tType = tVal.Type()
+	if tType.Kind() != reflect.Ptr && tType.Kind() != reflect.Struct {
+		return false, fmt.Errorf("requires struct target, got %s", tType)
+	}
 	if tType.Kind() == reflect.Ptr {
 		if tVal.IsNil() {
 			structVal := reflect.New(tType.Elem())
 			loadedAny, err = l.loadFromEnv(lookupPrefix, structVal.Interface(),
-				parentIsRequired, true, fieldPath, fieldDocs)
+				parentIsRequired, true, fieldPath, st)
 			if loadedAny {
 				tVal.Set(structVal)
 			}
 		} else {
 			loadedAny, err = l.loadFromEnv(lookupPrefix, tVal.Interface(),
-				parentIsRequired, reqCancel, fieldPath, fieldDocs)
+				parentIsRequired, reqCancel, fieldPath, st)
 		}
 		return
 	}
@@ -144,90 +321,61 @@ func (l Loader) loadFromEnv(
 			continue
 		}
 
-		fTag := fInfo.Tag.Get(tagName)
-		var fTagName string
-		var fTagOpts fieldTagOpts
-		if fTag != "" {
-			fTagParts := strings.SplitN(fTag, ",", 2)
-			fTagName = fTagParts[0]
-			if len(fTagParts) > 1 {
-				fTagOpts, _ = parseFieldTagOpts(fTagParts[1])
+		fTagName, fTagOpts, ok, err := l.parseFieldTag(fInfo)
+		if err != nil {
+			if err = st.fail(err); err != nil {
+				return loadedAny, err
 			}
+			continue
 		}
-		if fTagName != "" {
-			if fTagName == l.IgnoredStructFieldName {
-				continue
-			}
-			if fTagName == l.SquashStructFieldName {
-				fTagName = ""
-				fTagOpts.Squash = true
-			}
-
-			if strings.HasPrefix(fTagName, "!") {
-				if fTagOpts.Squash {
-					// Note that this should be possible but it'll be
-					// quite complex (and there's probably no use case)
-					return false, fmt.Errorf("cannot combine noprefix with squash (field %s)", fTagName)
-				}
-				fTagOpts.NoPrefix = true
-				fTagName = strings.TrimPrefix(fTagName, "!")
-				if fTagName == "" {
-					fTagName = l.convertFieldName(fInfo.Name)
-				}
-			}
-		} else {
-			if !fInfo.Anonymous {
-				fTagName = l.convertFieldName(fInfo.Name)
-			} else {
-				fTagOpts.Squash = true
-			}
+		if !ok {
+			continue
 		}
 
 		fType := fInfo.Type
-		if fType.Kind() == reflect.Struct || (fType.Kind() == reflect.Ptr && fType.Elem().Kind() == reflect.Struct) {
-			if fTagName != "" {
-				var lookupKey string
-				if fTagOpts.NoPrefix {
-					lookupKey = fTagName
-				} else {
-					lookupKey = lookupPrefix + fTagName
-				}
-
-				//TODO: docs for field which type is an opaque struct. we will
-				// provide an unmarshaller interface for field value types.
-				// if the type of a field value conforms the interface, then
-				// we'll treat it as opaque.
-
-				if strVal, exists := lookupEnv(lookupKey); exists {
-					fieldLoaded, err := l.loadFieldValue(strVal, fVal)
-					if err != nil {
-						return loadedAny, fmt.Errorf("unable to load field value (field %s key %s): %w",
-							fInfo.Name, lookupKey, err)
+		// A struct which could be decoded from a single string is opaque;
+		// it's loaded and documented as a whole from a single key, like
+		// the other values, and its own fields are not looked up.
+		isOpaque := fTagName != "" && isOpaqueType(fType)
+		if !isOpaque && (fType.Kind() == reflect.Struct ||
+			(fType.Kind() == reflect.Ptr && fType.Elem().Kind() == reflect.Struct)) {
+			fieldPrefix := l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts)
+			var parentSection *DocsSection
+			if docsMode {
+				parentSection = st.section
+				descriptor, err := l.fieldDocsDescriptor(target, fInfo, fTagName)
+				if err != nil {
+					if err = st.fail(err); err != nil {
+						return loadedAny, err
 					}
-					loadedAny = loadedAny || fieldLoaded
-					continue
-				}
-			}
-
-			var fieldPrefix string
-			if fTagOpts.Squash {
-				fieldPrefix = lookupPrefix
-			} else {
-				if fTagOpts.NoPrefix {
-					fieldPrefix = fTagName + nsSep
-				} else {
-					fieldPrefix = lookupPrefix + fTagName + nsSep
 				}
+				st.section = parentSection.addSection(&DocsSection{
+					Path:        fieldPath + "." + fInfo.Name,
+					Prefix:      fieldPrefix,
+					Type:        structType(fType),
+					Self:        l.selfDocsDescriptor(structPointer(fVal)),
+					Description: descriptor.Description,
+					Optional:    fType.Kind() == reflect.Ptr,
+					Required:    fTagOpts.Required,
+					Squashed:    fTagOpts.Squash,
+				})
 			}
 			fieldLoaded, err := l.loadFromEnv(fieldPrefix, fVal.Addr().Interface(),
-				fTagOpts.Required || parentIsRequired, true, fieldPath+"."+fInfo.Name, fieldDocs)
+				fTagOpts.Required || parentIsRequired, true, fieldPath+"."+fInfo.Name, st)
+			if docsMode {
+				st.section = parentSection
+			}
 			if err != nil {
 				return loadedAny, fmt.Errorf("unable to load field value (field %s key %s*): %w",
 					fInfo.Name, fieldPrefix, err)
 			}
-			if !fieldLoaded && fTagOpts.Required {
-				return loadedAny, fmt.Errorf("field is required (field %s key %s*)",
-					fInfo.Name, fieldPrefix)
+			if !docsMode && !fieldLoaded && fTagOpts.Required &&
+				!(l.NoOverride && !fVal.IsZero()) {
+				err = st.fail(fmt.Errorf("field is required (field %s key %s*)",
+					fInfo.Name, fieldPrefix))
+				if err != nil {
+					return loadedAny, err
+				}
 			}
 			loadedAny = loadedAny || fieldLoaded
 			continue
@@ -235,34 +383,57 @@ func (l Loader) loadFromEnv(
 
 		if fType.Kind() == reflect.Map && fTagOpts.Map {
 			if fType.Key().Kind() != reflect.String {
-				return loadedAny, fmt.Errorf("map requires an instance of map with string key")
-			}
-			var fmBasePrefix string
-			if fTagOpts.Squash {
-				fmBasePrefix = lookupPrefix
-			} else {
-				if fTagOpts.NoPrefix {
-					fmBasePrefix = fTagName + nsSep
-				} else {
-					fmBasePrefix = lookupPrefix + fTagName + nsSep
+				err = st.fail(fmt.Errorf("map requires an instance of map with string key (field %s)",
+					fInfo.Name))
+				if err != nil {
+					return loadedAny, err
 				}
+				continue
 			}
-			for _, entryKey := range fVal.MapKeys() {
+			fmBasePrefix := l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts)
+			entryKeys := fVal.MapKeys()
+			sort.Slice(entryKeys, func(i, j int) bool {
+				return entryKeys[i].String() < entryKeys[j].String()
+			})
+			for _, entryKey := range entryKeys {
 				mapEntryKey := entryKey.Interface().(string)
 				mapEntryVal := fVal.MapIndex(entryKey).Interface()
 				rmeVal := reflect.ValueOf(mapEntryVal)
 				rmeType := rmeVal.Type()
 				if rmeType.Kind() != reflect.Ptr {
-					return false, fmt.Errorf("requires pointer target (field %s key %s)", fInfo.Name, mapEntryKey)
+					err = st.fail(fmt.Errorf("requires pointer target (field %s key %s)", fInfo.Name, mapEntryKey))
+					if err != nil {
+						return loadedAny, err
+					}
+					continue
 				}
 				// Notes: might try to instantiate, but we won't support it for now.
 				if rmeVal.IsNil() && !rmeVal.CanSet() {
-					return false, fmt.Errorf("requires settable target (field %s key %s)", fInfo.Name, mapEntryKey)
+					err = st.fail(fmt.Errorf("requires settable target (field %s key %s)", fInfo.Name, mapEntryKey))
+					if err != nil {
+						return loadedAny, err
+					}
+					continue
 				}
 				fmPrefix := fmBasePrefix + strings.ToUpper(mapEntryKey) + nsSep
+				fmPath := fieldPath + "." + fInfo.Name + "[" + mapEntryKey + ": " + rmeType.String() + "]"
+				var parentSection *DocsSection
+				if docsMode {
+					parentSection = st.section
+					st.section = parentSection.addSection(&DocsSection{
+						Path:     fmPath,
+						Prefix:   fmPrefix,
+						Type:     structType(rmeType),
+						Self:     l.selfDocsDescriptor(mapEntryVal),
+						Required: fTagOpts.Required,
+						MapEntry: &MapEntryDocs{Field: fInfo.Name, Key: mapEntryKey},
+					})
+				}
 				mapEntryLoaded, err := l.loadFromEnv(fmPrefix, rmeVal.Interface(),
-					fTagOpts.Required || parentIsRequired, true,
-					fieldPath+"."+fInfo.Name+"["+mapEntryKey+": "+rmeType.String()+"]", fieldDocs)
+					fTagOpts.Required || parentIsRequired, true, fmPath, st)
+				if docsMode {
+					st.section = parentSection
+				}
 				if err != nil {
 					return loadedAny, fmt.Errorf("map entry loading failed: %w (field %s key %s)",
 						err, fInfo.Name, mapEntryKey)
@@ -271,100 +442,352 @@ func (l Loader) loadFromEnv(
 				loadedAny = loadedAny || mapEntryLoaded
 			}
 
+			if docsMode {
+				err = l.mapEntryPlaceholderDocs(fmBasePrefix, fInfo, fTagOpts,
+					fVal, fieldPath, st)
+				if err != nil {
+					return loadedAny, err
+				}
+			}
 			continue
 		}
 
 		if fTagOpts.Squash {
-			return loadedAny, fmt.Errorf("squash can only be used to "+
+			err = st.fail(fmt.Errorf("squash can only be used to "+
 				"field which type is struct or pointer "+
-				"to struct (field %s)", fInfo.Name)
+				"to struct (field %s)", fInfo.Name))
+			if err != nil {
+				return loadedAny, err
+			}
+			continue
 		}
 
-		var lookupKey string
-		if fTagOpts.NoPrefix {
-			lookupKey = fTagName
-		} else {
-			lookupKey = lookupPrefix + fTagName
-		}
-		if !fTagOpts.DocsHidden && fieldDocs != nil {
-			var desc string
-			var descriptor *FieldDocsDescriptor
-			var availableValues map[string]EnumValueDocs
-			if fd, ok := target.(fieldDocsDescriptorProvider); ok {
-				descriptor = fd.FieldDocsDescriptor(fInfo.Name)
-				if descriptor == nil {
-					descriptor = fd.FieldDocsDescriptor(fTagName)
-				}
-				if descriptor != nil {
-					desc = descriptor.Description
-					availableValues = descriptor.AvailableValues
-				}
-			}
-			if desc == "" {
-				if fd, ok := target.(namespacedFieldDescriptionsProvider); ok {
-					fieldDescs := fd.StevFieldDescriptions()
-					desc, ok = fieldDescs[fInfo.Name]
-					if !ok {
-						desc = fieldDescs[fTagName]
-					}
-				}
+		lookupKey := l.fieldLookupKey(lookupPrefix, fTagName, fTagOpts)
+		if err = st.claimKey(lookupKey, fieldPath+"."+fInfo.Name); err != nil {
+			if err = st.fail(err); err != nil {
+				return loadedAny, err
 			}
-			if desc == "" {
-				if fd, ok := target.(fieldDescriptionsProvider); ok {
-					fieldDescs := fd.FieldDescriptions()
-					desc, ok = fieldDescs[fInfo.Name]
-					if !ok {
-						desc = fieldDescs[fTagName]
-					}
+			continue
+		}
+		if fieldDocs != nil && (!fTagOpts.DocsHidden || st.includeHidden) {
+			descriptor, err := l.fieldDocsDescriptor(target, fInfo, fTagName)
+			if err != nil {
+				if err = st.fail(err); err != nil {
+					return loadedAny, err
 				}
 			}
-			//TODO: use our own interface for converting the values from/to string
 			var defVal string
 			if fType.Kind() == reflect.Ptr {
-				if fVal.IsNil() {
-					defVal = ""
-				} else {
-					defVal = fmt.Sprintf("%v", fVal.Elem())
+				if !fVal.IsNil() {
+					defVal = l.formatFieldValue(fVal.Elem())
 				}
 			} else if !fVal.IsZero() {
-				defVal = fmt.Sprintf("%v", fVal.Interface())
+				defVal = l.formatFieldValue(fVal)
 			}
-			*fieldDocs = append(*fieldDocs, FieldDocs{
+			fd := FieldDocs{
 				LookupKey:       lookupKey,
 				DataType:        fType.String(),
+				Type:            fType,
+				Kind:            KindOf(fType),
+				TypeHint:        TypeHintOf(fType),
+				Nullable:        fType.Kind() == reflect.Ptr,
+				Placeholder:     st.placeholderDepth > 0,
+				Hidden:          fTagOpts.DocsHidden,
+				Secret:          fTagOpts.Secret,
 				Required:        fTagOpts.Required,
-				Description:     strings.TrimSpace(desc),
+				Description:     descriptor.Description,
+				Example:         descriptor.Example,
+				Since:           descriptor.Since,
+				Deprecated:      descriptor.Deprecated,
 				Value:           defVal,
 				Path:            fieldPath + "." + fInfo.Name,
-				AvailableValues: availableValues,
-			})
+				AvailableValues: descriptor.AvailableValues,
+			}
+			*fieldDocs = append(*fieldDocs, fd)
+			st.section.Fields = append(st.section.Fields, fd)
+		}
+		// The docs are collected without reading the sources, which
+		// leaves the values of the skeleton untouched.
+		if docsMode {
+			continue
+		}
+		if l.NoOverride && !fVal.IsZero() {
+			continue
 		}
-		if strVal, exists := lookupEnv(lookupKey); exists {
+		if strVal, exists := l.LookupEnv(lookupKey); exists {
 			fieldLoaded, err := l.loadFieldValue(strVal, fVal)
-			if err != nil {
-				return loadedAny, fmt.Errorf("unable to load field value (field %s key %s): %w",
-					fInfo.Name, lookupKey, err)
+			if err == nil {
+				loadedAny = loadedAny || fieldLoaded
+				continue
 			}
-			loadedAny = loadedAny || fieldLoaded
-			continue
-		} else {
-			if !docsMode && fTagOpts.Required {
-				if parentIsRequired || !reqCancel {
-					return loadedAny, fmt.Errorf("field is required (field %s key %s)",
-						fInfo.Name, lookupKey)
+			fieldErr := &FieldError{
+				Field: fInfo.Name, Path: fieldPath + "." + fInfo.Name,
+				LookupKey: lookupKey, Err: err,
+			}
+			if !l.skipFieldError(fieldErr) {
+				if err = st.fail(fieldErr); err != nil {
+					return loadedAny, err
 				}
-				unsatisfiedFields = append(unsatisfiedFields, fieldKey{fInfo.Name, lookupKey})
+				continue
 			}
+			// A skipped value doesn't satisfy the required constraint;
+			// the field is treated as missing.
+		}
+		if fTagOpts.Required {
+			if parentIsRequired || !reqCancel {
+				err = st.fail(fmt.Errorf("field is required (field %s key %s)",
+					fInfo.Name, lookupKey))
+				if err != nil {
+					return loadedAny, err
+				}
+				continue
+			}
+			unsatisfiedFields = append(unsatisfiedFields, fieldKey{fInfo.Name, lookupKey})
 		}
 	}
 
 	if !docsMode && loadedAny && len(unsatisfiedFields) > 0 {
-		return loadedAny, fmt.Errorf("fields are required %v", unsatisfiedFields)
+		if err = st.fail(fmt.Errorf("fields are required %v", unsatisfiedFields)); err != nil {
+			return loadedAny, err
+		}
 	}
 
 	return
 }
 
+// fieldDocsDescriptor resolves the docs of a field. The docs-descriptors
+// provided by target, the struct containing the field, take precedence
+// over the docs registered for its type, which take precedence over the
+// docs tag of the field.
+func (l Loader) fieldDocsDescriptor(
+	target interface{}, fInfo reflect.StructField, tagName string,
+) (FieldDocsDescriptor, error) {
+	var descriptor FieldDocsDescriptor
+	if fd, ok := target.(fieldDocsDescriptorProvider); ok {
+		d := fd.FieldDocsDescriptor(fInfo.Name)
+		if d == nil {
+			d = fd.FieldDocsDescriptor(tagName)
+		}
+		if d != nil {
+			descriptor = *d
+		}
+	}
+	if descriptor.Description == "" {
+		if fd, ok := target.(namespacedFieldDescriptionsProvider); ok {
+			fieldDescs := fd.StevFieldDescriptions()
+			desc, ok := fieldDescs[fInfo.Name]
+			if !ok {
+				desc = fieldDescs[tagName]
+			}
+			descriptor.Description = desc
+		}
+	}
+	if descriptor.Description == "" {
+		if fd, ok := target.(fieldDescriptionsProvider); ok {
+			fieldDescs := fd.FieldDescriptions()
+			desc, ok := fieldDescs[fInfo.Name]
+			if !ok {
+				desc = fieldDescs[tagName]
+			}
+			descriptor.Description = desc
+		}
+	}
+	if typeDocs, ok := l.registeredTypeDocs(target); ok {
+		d := typeDocs.Fields[fInfo.Name]
+		if d == nil {
+			d = typeDocs.Fields[tagName]
+		}
+		if d != nil {
+			if descriptor.Description == "" {
+				descriptor.Description = d.Description
+			}
+			if descriptor.AvailableValues == nil {
+				descriptor.AvailableValues = d.AvailableValues
+			}
+			if descriptor.Example == "" {
+				descriptor.Example = d.Example
+			}
+			if descriptor.Since == "" {
+				descriptor.Since = d.Since
+			}
+			if descriptor.Deprecated == "" {
+				descriptor.Deprecated = d.Deprecated
+			}
+		}
+	}
+
+	if docsTag, ok := fInfo.Tag.Lookup(l.DocsTagKey); ok {
+		tagDocs, err := parseDocsTag(docsTag)
+		if err != nil && l.Strict {
+			return descriptor, fmt.Errorf("%w (field %s)", err, fInfo.Name)
+		}
+		if descriptor.Description == "" {
+			descriptor.Description = tagDocs.Description
+		}
+		if descriptor.Example == "" {
+			descriptor.Example = tagDocs.Example
+		}
+		if descriptor.Since == "" {
+			descriptor.Since = tagDocs.Since
+		}
+		if descriptor.Deprecated == "" {
+			descriptor.Deprecated = tagDocs.Deprecated
+		}
+	}
+	descriptor.Description = strings.TrimSpace(descriptor.Description)
+	return descriptor, nil
+}
+
+// parseDocsTag parses a docs tag in the form of
+// `desc=...;example=...;since=...;deprecated=...`. A semicolon
+// which is part of a value must be escaped with a backslash, which
+// is written as \\; in the struct tag. The known entries are returned
+// even if there's an error.
+func parseDocsTag(str string) (FieldDocsDescriptor, error) {
+	var descriptor FieldDocsDescriptor
+	var err error
+	for _, entry := range splitDocsTag(str) {
+		name, value, _ := strings.Cut(entry, "=")
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(name) {
+		case "":
+			continue
+		case "desc":
+			descriptor.Description = value
+		case "example":
+			descriptor.Example = value
+		case "since":
+			descriptor.Since = value
+		case "deprecated":
+			descriptor.Deprecated = value
+		default:
+			if err == nil {
+				err = fmt.Errorf("unknown docs tag entry %q", name)
+			}
+		}
+	}
+	return descriptor, err
+}
+
+// splitDocsTag splits str at the semicolons which are not escaped.
+func splitDocsTag(str string) []string {
+	var entries []string
+	var sb strings.Builder
+	for i := 0; i < len(str); i++ {
+		switch {
+		case str[i] == '\\' && i+1 < len(str) && str[i+1] == ';':
+			sb.WriteByte(';')
+			i++
+		case str[i] == ';':
+			entries = append(entries, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(str[i])
+		}
+	}
+	return append(entries, sb.String())
+}
+
+// skipFieldError returns true if the loader is in best-effort mode, in
+// which case the error is reported instead of being returned.
+func (l Loader) skipFieldError(fieldErr *FieldError) bool {
+	if !l.BestEffort {
+		return false
+	}
+	if l.OnFieldError != nil {
+		l.OnFieldError(fieldErr)
+	}
+	return true
+}
+
+// parseFieldTag resolves the name and the options of a struct field
+// from its tag. The returned ok is false if the field must be ignored.
+func (l Loader) parseFieldTag(
+	fInfo reflect.StructField,
+) (fTagName string, fTagOpts fieldTagOpts, ok bool, err error) {
+	fTag := fInfo.Tag.Get(l.StructFieldTagKey)
+	if fTag != "" {
+		fTagParts := strings.SplitN(fTag, ",", 2)
+		fTagName = fTagParts[0]
+		if len(fTagParts) > 1 {
+			fTagOpts, err = parseFieldTagOpts(fTagParts[1])
+			if err != nil && l.Strict {
+				return "", fTagOpts, false, fmt.Errorf("%w (field %s)", err, fInfo.Name)
+			}
+			err = nil
+		}
+	}
+	if fTagName != "" {
+		if fTagName == l.IgnoredStructFieldName {
+			return "", fTagOpts, false, nil
+		}
+		if fTagName == l.SquashStructFieldName {
+			fTagName = ""
+			fTagOpts.Squash = true
+		}
+
+		if strings.HasPrefix(fTagName, "!") {
+			if fTagOpts.Squash {
+				// Note that this should be possible but it'll be
+				// quite complex (and there's probably no use case)
+				return "", fTagOpts, false, fmt.Errorf("cannot combine noprefix with squash (field %s)", fTagName)
+			}
+			fTagOpts.NoPrefix = true
+			fTagName = strings.TrimPrefix(fTagName, "!")
+			if fTagName == "" {
+				fTagName = l.convertFieldName(fInfo.Name)
+			}
+		}
+	} else {
+		if !fInfo.Anonymous {
+			if l.IgnoreUntagged && fTag == "" {
+				return "", fTagOpts, false, nil
+			}
+			fTagName = l.convertFieldName(fInfo.Name)
+		} else {
+			fTagOpts.Squash = true
+		}
+	}
+	return fTagName, fTagOpts, true, nil
+}
+
+// fieldLookupKey returns the key used to look up the value of a field.
+func (l Loader) fieldLookupKey(
+	lookupPrefix string, fTagName string, fTagOpts fieldTagOpts,
+) string {
+	if fTagOpts.NoPrefix {
+		return fTagName
+	}
+	return lookupPrefix + fTagName
+}
+
+// fieldLookupPrefix returns the prefix for the keys of the fields of
+// a nested struct or the entries of a map.
+func (l Loader) fieldLookupPrefix(
+	lookupPrefix string, fTagName string, fTagOpts fieldTagOpts,
+) string {
+	if fTagOpts.Squash {
+		return lookupPrefix
+	}
+	if fTagOpts.NoPrefix {
+		return fTagName + l.NamespaceSeparator
+	}
+	return lookupPrefix + fTagName + l.NamespaceSeparator
+}
+
+// CheckValue returns an error if strVal can't be loaded into a field
+// of type t using default Loader.
+func CheckValue(t reflect.Type, strVal string) error {
+	return defaultLoader.CheckValue(t, strVal)
+}
+
+// CheckValue returns an error if strVal can't be loaded into a field
+// of type t, e.g., the Type of a FieldDocs.
+func (l Loader) CheckValue(t reflect.Type, strVal string) error {
+	_, err := l.withDefaults().loadFieldValue(strVal, reflect.New(t).Elem())
+	return err
+}
+
 func (l Loader) loadFieldValue(
 	strVal string, fieldValue reflect.Value,
 ) (loaded bool, err error) {
@@ -393,6 +816,15 @@ func (l Loader) loadFieldValue(
 		return true, nil
 	}
 
+	if fieldValue.CanAddr() {
+		if tu, ok := fieldValue.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			if err := tu.UnmarshalText([]byte(strVal)); err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+
 	switch fieldType.Kind() {
 	case reflect.Bool:
 		if strVal == "" {
@@ -416,7 +848,7 @@ func (l Loader) loadFieldValue(
 		}
 		fieldValue.SetFloat(v)
 		return true, nil
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		if strVal == "" {
 			fieldValue.SetInt(0)
 			return true, nil
@@ -427,7 +859,7 @@ func (l Loader) loadFieldValue(
 		}
 		fieldValue.SetInt(v)
 		return true, nil
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		if strVal == "" {
 			fieldValue.SetUint(0)
 			return true, nil
@@ -446,37 +878,6 @@ func (l Loader) loadFieldValue(
 	}
 }
 
-func (l Loader) convertFieldName(fieldName string) string {
-	if fieldName == "" {
-		return ""
-	}
-	var outRunes []rune
-	prevIsUpper := true
-	for _, r := range fieldName {
-		if unicode.IsUpper(r) || unicode.IsDigit(r) {
-			if prevIsUpper {
-				outRunes = append(outRunes, r)
-				continue
-			}
-			outRunes = append(outRunes, '_', r)
-			prevIsUpper = true
-		} else {
-			if prevIsUpper && len(outRunes) >= 2 {
-				cR := outRunes[len(outRunes)-2]
-				if unicode.IsUpper(cR) || unicode.IsDigit(cR) {
-					tR := outRunes[len(outRunes)-1]
-					outRunes[len(outRunes)-1] = '_'
-					outRunes = append(outRunes, tR)
-				}
-			}
-			outRunes = append(outRunes, r)
-			prevIsUpper = false
-		}
-	}
-	tagName := strings.ToUpper(string(outRunes))
-	return tagName
-}
-
 type fieldTagOpts struct {
 	NoPrefix bool
 	Squash   bool
@@ -487,6 +888,14 @@ type fieldTagOpts struct {
 	// tuning fields to prevent them from distracting from the necessary
 	// fields.
 	DocsHidden bool
+
+	// The field can't be hot-swapped by a Watcher; changing its value
+	// requires a restart of the application.
+	Static bool
+
+	// The value is sensitive, e.g., a password or a private key. It's
+	// used by the docs generators to place the value accordingly.
+	Secret bool
 }
 
 func parseFieldTagOpts(str string) (fieldTagOpts, error) {
@@ -494,6 +903,9 @@ func parseFieldTagOpts(str string) (fieldTagOpts, error) {
 		return fieldTagOpts{}, nil
 	}
 	opts := fieldTagOpts{}
+	// The unknown options don't stop the parsing so that the rest of
+	// the options are still applied when they are tolerated.
+	var err error
 	parts := strings.Split(str, ",")
 	for _, s := range parts {
 		switch s {
@@ -505,9 +917,17 @@ func parseFieldTagOpts(str string) (fieldTagOpts, error) {
 			opts.Map = true
 		case "docs_hidden":
 			opts.DocsHidden = true
+		case "static":
+			opts.Static = true
+		case "secret":
+			opts.Secret = true
+		default:
+			if err == nil {
+				err = fmt.Errorf("unknown tag option %q", s)
+			}
 		}
 	}
-	return opts, nil
+	return opts, err
 }
 
 type FieldDocs struct {
@@ -516,6 +936,42 @@ type FieldDocs struct {
 	Required    bool
 	Description string
 
+	// The Go type of the field. DataType is its string representation.
+	Type reflect.Type
+
+	// The normalized kind of the value.
+	Kind FieldKind
+
+	// A description of the accepted values, e.g., "integer 0 to 65535".
+	// See TypeHintOf.
+	TypeHint string
+
+	// The field is a pointer; not setting the value leaves it nil.
+	Nullable bool
+
+	// The field is in a map-entry placeholder; the LookupKey contains
+	// placeholders, e.g., <NAME>, to be replaced with the keys of the
+	// entries. See MapEntryDocs.
+	Placeholder bool
+
+	// The field has the docs_hidden tag option. Such fields are only
+	// included by AllDocs.
+	Hidden bool
+
+	// The field has the secret tag option; its value is sensitive.
+	Secret bool
+
+	// An example value, for fields whose default value, if any, is not
+	// illustrative enough.
+	Example string
+
+	// The version which introduced the field.
+	Since string
+
+	// If it's not empty, the field is deprecated. It tells why, or what
+	// to use instead.
+	Deprecated string
+
 	// The value as provided through the skeleton. This might be
 	// the default or the suggested value.
 	Value string
@@ -534,6 +990,11 @@ type FieldDocsDescriptor struct {
 	Description string
 	// The key is the the available value.
 	AvailableValues map[string]EnumValueDocs
+
+	// See the fields of the same names in FieldDocs.
+	Example    string
+	Since      string
+	Deprecated string
 }
 
 type SelfDocsDescriptor struct {
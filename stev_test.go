@@ -3,6 +3,7 @@ package stev_test
 import (
 	"errors"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -709,3 +710,148 @@ func assertInt64Eq(t *testing.T, have, wanted int64) {
 		t.Errorf("Assertion failed:\n\twanted: %v\n\thave:   %v", wanted, have)
 	}
 }
+
+type DocsTagConfig struct {
+	Host    string `envdoc:"desc=The host name.;example=db.local;since=1.4"`
+	Timeout int32  `envdoc:"desc=Seconds\\;0 disables it.;deprecated=use DEADLINE"`
+	Tagged  string `env:"TAGGED" envdoc:"desc=Ignored."`
+}
+
+func (DocsTagConfig) FieldDescriptions() map[string]string {
+	return map[string]string{"Tagged": "From the descriptor."}
+}
+
+func TestDocsTag(t *testing.T) {
+	fieldDocs, err := stev.Docs("", &DocsTagConfig{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(fieldDocs) != 3 {
+		t.Fatalf("Expected 3 fields, got %d", len(fieldDocs))
+	}
+	assertStrEq(t, fieldDocs[0].Description, "The host name.")
+	assertStrEq(t, fieldDocs[0].Example, "db.local")
+	assertStrEq(t, fieldDocs[0].Since, "1.4")
+	assertStrEq(t, fieldDocs[1].Description, "Seconds;0 disables it.")
+	assertStrEq(t, fieldDocs[1].Deprecated, "use DEADLINE")
+	assertStrEq(t, fieldDocs[2].Description, "From the descriptor.")
+}
+
+type UnknownDocsTagConfig struct {
+	Host string `envdoc:"desc=The host name.;unit=ms"`
+}
+
+func TestDocsTagUnknownEntry(t *testing.T) {
+	if _, err := stev.Docs("", &UnknownDocsTagConfig{}); err != nil {
+		t.Errorf("Expected nil, got %#v", err)
+	}
+	l, err := stev.NewLoader(stev.WithStrict(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Docs("", &UnknownDocsTagConfig{}); err == nil ||
+		!strings.Contains(err.Error(), `unknown docs tag entry "unit"`) {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestTypeHintOf(t *testing.T) {
+	for _, tc := range []struct {
+		v        interface{}
+		expected string
+	}{
+		{new(*time.Duration), "duration (e.g. 30s, 1h15m)"},
+		{new(uint16), "integer 0 to 65535"},
+		{new(int8), "integer -128 to 127"},
+		{new(int64), "integer"},
+		{new(bool), "boolean (true/false/1/0; empty means true)"},
+		{new([]string), ""},
+		{new(map[string]interface{}), ""},
+	} {
+		assertStrEq(t, stev.TypeHintOf(reflect.TypeOf(tc.v).Elem()), tc.expected)
+	}
+}
+
+// DSN is a struct which is decoded from a single value and documents
+// its format.
+type DSN struct {
+	Host string
+	Name string
+}
+
+func (d *DSN) UnmarshalText(text []byte) error {
+	host, name, ok := strings.Cut(string(text), "/")
+	if !ok {
+		return errors.New("missing database name")
+	}
+	d.Host, d.Name = host, name
+	return nil
+}
+
+func (DSN) StevTypeHint() string {
+	return "database DSN in the form of host/name"
+}
+
+type OpaqueConfig struct {
+	Database DSN `env:",required"`
+	Listen   *HostPort
+}
+
+func TestOpaqueStruct(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DATABASE", "db.local/app")
+	os.Setenv("DATABASE_HOST", "ignored")
+	os.Setenv("LISTEN", "localhost:8080")
+	var cfg OpaqueConfig
+	if err := stev.LoadFromEnv("", &cfg); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, cfg.Database.Host, "db.local")
+	assertStrEq(t, cfg.Database.Name, "app")
+	if cfg.Listen == nil || cfg.Listen.Port != "8080" {
+		t.Errorf("Unexpected value %#v", cfg.Listen)
+	}
+
+	os.Unsetenv("DATABASE")
+	err := stev.LoadFromEnv("", &OpaqueConfig{})
+	if err == nil || !strings.Contains(err.Error(), "field is required (field Database key DATABASE)") {
+		t.Errorf("Unexpected error %v", err)
+	}
+
+	fieldDocs, err := stev.Docs("", &OpaqueConfig{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(fieldDocs) != 2 {
+		t.Fatalf("Expected 2 fields, got %#v", fieldDocs)
+	}
+	assertStrEq(t, fieldDocs[0].LookupKey, "DATABASE")
+	assertStrEq(t, fieldDocs[0].TypeHint, "database DSN in the form of host/name")
+	if !fieldDocs[0].Required || fieldDocs[0].Kind != stev.FieldKindString {
+		t.Errorf("Unexpected docs %#v", fieldDocs[0])
+	}
+	assertStrEq(t, fieldDocs[1].LookupKey, "LISTEN")
+	assertStrEq(t, fieldDocs[1].TypeHint, "text in the format of stev_test.HostPort")
+}
+
+func TestCheckValue(t *testing.T) {
+	for _, tc := range []struct {
+		v     interface{}
+		val   string
+		valid bool
+	}{
+		{new(uint16), "8080", true},
+		{new(uint16), "70000", false},
+		{new(*time.Duration), "1m", true},
+		{new(time.Duration), "1 minute", false},
+		{new(bool), "", true},
+		{new(bool), "yes", false},
+		{new(DSN), "localhost/app", true},
+		{new(DSN), "localhost", false},
+	} {
+		err := stev.CheckValue(reflect.TypeOf(tc.v).Elem(), tc.val)
+		if (err == nil) != tc.valid {
+			t.Errorf("Unexpected result for %q into %T: %v", tc.val, tc.v, err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package stev
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// TypeDocs holds the docs of a struct type which doesn't provide its own
+// docs-descriptors, e.g., a struct from another module. See RegisterDocs
+// and Describe.
+type TypeDocs struct {
+	Self *SelfDocsDescriptor
+
+	// The docs of the fields. The keys are the names of the fields, or
+	// the names in their tags.
+	Fields map[string]*FieldDocsDescriptor
+}
+
+// typeDocsRegistry holds the docs registered for struct types.
+type typeDocsRegistry struct {
+	mu   sync.RWMutex
+	docs map[reflect.Type]TypeDocs
+}
+
+var globalTypeDocs typeDocsRegistry
+
+func (r *typeDocsRegistry) register(t reflect.Type, docs TypeDocs) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.docs == nil {
+		r.docs = map[reflect.Type]TypeDocs{}
+	}
+	r.docs[t] = docs
+}
+
+func (r *typeDocsRegistry) lookup(t reflect.Type) (TypeDocs, bool) {
+	if r == nil {
+		return TypeDocs{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	docs, ok := r.docs[t]
+	return docs, ok
+}
+
+// Describe registers the docs of the struct type T for all the loaders.
+// The docs are used when T doesn't provide its own docs-descriptors.
+//
+//	stev.Describe[redis.Options](stev.TypeDocs{
+//		Fields: map[string]*stev.FieldDocsDescriptor{
+//			"Addr": {Description: "The address of the server."},
+//		},
+//	})
+func Describe[T any](docs TypeDocs) {
+	globalTypeDocs.register(docsStructType(reflect.TypeOf((*T)(nil))), docs)
+}
+
+// RegisterDocs registers the docs of a struct type for this loader and
+// its copies. The type is provided either as a reflect.Type or as a
+// sample value of the type, or a pointer to it. The docs registered
+// with the loader take precedence over those registered with Describe.
+//
+// It panics if the type is not a struct type.
+func (l *Loader) RegisterDocs(typeOrSample interface{}, docs TypeDocs) {
+	t, ok := typeOrSample.(reflect.Type)
+	if !ok {
+		t = reflect.TypeOf(typeOrSample)
+	}
+	if l.typeDocs == nil {
+		l.typeDocs = &typeDocsRegistry{}
+	}
+	l.typeDocs.register(docsStructType(t), docs)
+}
+
+// docsStructType dereferences t down to the struct type. It panics if
+// there's no struct type.
+func docsStructType(t reflect.Type) reflect.Type {
+	st := structType(t)
+	if st == nil || st.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("stev: docs can only be registered for struct types, got %v", t))
+	}
+	return st
+}
+
+// registeredTypeDocs returns the docs registered for the type of the
+// struct pointed by target.
+func (l Loader) registeredTypeDocs(target interface{}) (TypeDocs, bool) {
+	t := structType(reflect.TypeOf(target))
+	if t == nil {
+		return TypeDocs{}, false
+	}
+	if docs, ok := l.typeDocs.lookup(t); ok {
+		return docs, true
+	}
+	return globalTypeDocs.lookup(t)
+}
+
+// selfDocsDescriptor is like LoadSelfDocsDescriptor but it falls back
+// to the registered docs.
+func (l Loader) selfDocsDescriptor(target interface{}) *SelfDocsDescriptor {
+	if self := LoadSelfDocsDescriptor(target); self != nil {
+		return self
+	}
+	if docs, ok := l.registeredTypeDocs(target); ok && docs.Self != nil {
+		self := *docs.Self
+		return &self
+	}
+	return nil
+}
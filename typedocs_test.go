@@ -0,0 +1,76 @@
+package stev_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rez-go/stev"
+)
+
+// ForeignOptions stands for a struct from another module.
+type ForeignOptions struct {
+	Addr     string
+	PoolSize int32 `env:"POOL"`
+}
+
+type ForeignDescribed struct {
+	Network string
+}
+
+type ForeignConfig struct {
+	Redis     ForeignOptions
+	Described ForeignDescribed
+}
+
+func TestRegisterDocs(t *testing.T) {
+	stev.Describe[ForeignDescribed](stev.TypeDocs{
+		Self: &stev.SelfDocsDescriptor{ShortDesc: "Described globally"},
+		Fields: map[string]*stev.FieldDocsDescriptor{
+			"Network": {Description: "The network."},
+		},
+	})
+
+	var l stev.Loader
+	l.RegisterDocs(reflect.TypeOf(ForeignOptions{}), stev.TypeDocs{
+		Self: &stev.SelfDocsDescriptor{ShortDesc: "Redis"},
+		Fields: map[string]*stev.FieldDocsDescriptor{
+			"Addr": {Description: "The address of the server."},
+			"POOL": {Description: "The size of the pool.", Since: "2.0"},
+		},
+	})
+
+	root, _, err := l.DocsTree("", &ForeignConfig{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	redis := root.Sections[0]
+	if redis.Self == nil || redis.Self.ShortDesc != "Redis" {
+		t.Errorf("Unexpected self docs %#v", redis.Self)
+	}
+	assertStrEq(t, redis.Fields[0].Description, "The address of the server.")
+	assertStrEq(t, redis.Fields[1].Description, "The size of the pool.")
+	assertStrEq(t, redis.Fields[1].Since, "2.0")
+
+	described := root.Sections[1]
+	if described.Self == nil || described.Self.ShortDesc != "Described globally" {
+		t.Errorf("Unexpected self docs %#v", described.Self)
+	}
+	assertStrEq(t, described.Fields[0].Description, "The network.")
+
+	// Registered with another loader
+	fieldDocs, err := stev.Docs("", &ForeignConfig{})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, fieldDocs[0].Description, "")
+}
+
+func TestRegisterDocsNonStruct(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Expected panic")
+		}
+	}()
+	var l stev.Loader
+	l.RegisterDocs("not a struct", stev.TypeDocs{})
+}
@@ -0,0 +1,392 @@
+package stev
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// WatcherPollIntervalDefault is the interval used by a Watcher to check
+// its files for changes when WatcherOptions.PollInterval is not set.
+const WatcherPollIntervalDefault = 5 * time.Second
+
+// WatcherOptions holds the options for NewWatcher.
+type WatcherOptions[T any] struct {
+	// The loader used to load the values. If it's nil, the default
+	// loader will be used.
+	Loader *Loader
+
+	// Dotenv-style files which provide the values. The files are
+	// consulted in order; a value from a later file overrides the same
+	// key from an earlier file. Keys which are not found in the files
+	// are looked up from the sources of the loader.
+	Files []string
+
+	// The interval to check the files for changes. The files are
+	// polled so that it works on every platform.
+	PollInterval time.Duration
+
+	// By default, the watcher reloads when the process receives SIGHUP.
+	// Set this to true to disable it.
+	IgnoreSIGHUP bool
+
+	// New creates the skeleton the values are loaded into. It's called
+	// on every reload so that each load starts from a fresh copy. If it's
+	// nil, the zero value of T is used.
+	New func() *T
+
+	// Validate is called on each freshly loaded copy before it's
+	// published. If T implements the Validate() error method, that
+	// method is called before this function.
+	Validate func(*T) error
+
+	// OnError receives the errors from the reloads triggered by Run.
+	OnError func(error)
+}
+
+// RestartNotice tells that a field marked with the static tag option has
+// changed. The change is not applied until the application restarts.
+type RestartNotice struct {
+	Path      string
+	LookupKey string
+}
+
+func (n RestartNotice) String() string {
+	return fmt.Sprintf("restart required to apply %s (field %s)", n.LookupKey, n.Path)
+}
+
+// Change is passed to the subscribers of a Watcher each time a new
+// configuration is published.
+type Change[T any] struct {
+	Old *T
+	New *T
+
+	// Fields which have changed but were kept at their old values
+	// because they are marked as static.
+	RestartRequired []RestartNotice
+}
+
+// Watcher keeps a configuration up to date by reloading it when
+// its files change or when the process receives SIGHUP.
+//
+// Each reload builds a fresh copy of the configuration. The copy is
+// published only if the loading and the validation succeed, thus
+// Current always returns a complete configuration.
+type Watcher[T any] struct {
+	prefix string
+	opts   WatcherOptions[T]
+	loader Loader
+
+	current atomic.Pointer[T]
+
+	mu          sync.Mutex
+	subscribers []func(Change[T])
+	fileStates  []watchedFileState
+}
+
+// NewWatcher creates a Watcher and performs the initial load.
+func NewWatcher[T any](prefix string, opts WatcherOptions[T]) (*Watcher[T], error) {
+	w := &Watcher[T]{
+		prefix: prefix,
+		opts:   opts,
+		loader: defaultLoader,
+	}
+	if opts.Loader != nil {
+		w.loader = opts.Loader.withDefaults()
+	}
+
+	w.fileStates = w.statFiles()
+	cfg, err := w.load()
+	if err != nil {
+		return nil, fmt.Errorf("stev: %w", err)
+	}
+	w.current.Store(cfg)
+	return w, nil
+}
+
+// Current returns the latest published configuration. The returned
+// value must be treated as read-only.
+func (w *Watcher[T]) Current() *T {
+	return w.current.Load()
+}
+
+// Subscribe registers fn to be called each time a new configuration
+// is published. fn is called without the watcher being locked; it could
+// call the methods of the watcher.
+func (w *Watcher[T]) Subscribe(fn func(Change[T])) {
+	w.mu.Lock()
+	w.subscribers = append(w.subscribers, fn)
+	w.mu.Unlock()
+}
+
+// Reload loads a fresh copy of the configuration and publishes it if
+// it differs from the current one. The current configuration is kept
+// if the loading or the validation fails.
+//
+// The subscribers are called after the watcher has been unlocked so
+// that they could call its methods.
+func (w *Watcher[T]) Reload() error {
+	w.mu.Lock()
+	change, changed, err := w.reloadLocked()
+	subscribers := append([]func(Change[T]){}, w.subscribers...)
+	w.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("stev: %w", err)
+	}
+	if !changed {
+		return nil
+	}
+	for _, fn := range subscribers {
+		fn(change)
+	}
+	return nil
+}
+
+// reloadLocked is Reload without the notification of the subscribers.
+// It must be called with the lock held.
+func (w *Watcher[T]) reloadLocked() (change Change[T], changed bool, err error) {
+	w.fileStates = w.statFiles()
+	next, err := w.load()
+	if err != nil {
+		return change, false, err
+	}
+
+	prev := w.current.Load()
+	notices := w.loader.keepStaticFields(w.prefix,
+		reflect.ValueOf(prev).Elem(), reflect.ValueOf(next).Elem(), "")
+	if len(notices) == 0 && reflect.DeepEqual(prev, next) {
+		return change, false, nil
+	}
+
+	w.current.Store(next)
+	return Change[T]{Old: prev, New: next, RestartRequired: notices}, true, nil
+}
+
+// Run watches for changes until ctx is done. Errors from the reloads
+// are passed to WatcherOptions.OnError.
+func (w *Watcher[T]) Run(ctx context.Context) error {
+	var sigCh chan os.Signal
+	if !w.opts.IgnoreSIGHUP {
+		sigCh = make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGHUP)
+		defer signal.Stop(sigCh)
+	}
+
+	var tickCh <-chan time.Time
+	if len(w.opts.Files) > 0 {
+		interval := w.opts.PollInterval
+		if interval <= 0 {
+			interval = WatcherPollIntervalDefault
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tickCh = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sigCh:
+			w.reload()
+		case <-tickCh:
+			if w.filesChanged() {
+				w.reload()
+			}
+		}
+	}
+}
+
+func (w *Watcher[T]) reload() {
+	if err := w.Reload(); err != nil && w.opts.OnError != nil {
+		w.opts.OnError(err)
+	}
+}
+
+func (w *Watcher[T]) load() (*T, error) {
+	fileEnv := map[string]string{}
+	for _, path := range w.opts.Files {
+		entries, err := ReadEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range entries {
+			fileEnv[k] = v
+		}
+	}
+
+	l := w.loader.withDefaults()
+	sources := l.Sources
+	if len(sources) == 0 {
+		sources = []Source{EnvSource()}
+	}
+	l.Sources = append([]Source{MapSource(fileEnv)}, sources...)
+
+	var cfg *T
+	if w.opts.New != nil {
+		cfg = w.opts.New()
+	} else {
+		cfg = new(T)
+	}
+	if err := l.load(w.prefix, cfg); err != nil {
+		return nil, err
+	}
+
+	if v, ok := interface{}(cfg).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+	if w.opts.Validate != nil {
+		if err := w.opts.Validate(cfg); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+type watchedFileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func (w *Watcher[T]) statFiles() []watchedFileState {
+	states := make([]watchedFileState, len(w.opts.Files))
+	for i, path := range w.opts.Files {
+		fi, err := os.Stat(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				// Force a reload which will report the error
+				states[i].size = -1
+			}
+			continue
+		}
+		states[i] = watchedFileState{
+			exists:  true,
+			size:    fi.Size(),
+			modTime: fi.ModTime(),
+		}
+	}
+	return states
+}
+
+func (w *Watcher[T]) filesChanged() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	states := w.statFiles()
+	for i := range states {
+		if states[i] != w.fileStates[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// keepStaticFields copies the values of the fields marked as static
+// from prev into next. It returns a notice for each static field whose
+// value differs.
+func (l Loader) keepStaticFields(
+	lookupPrefix string,
+	prev, next reflect.Value,
+	fieldPath string,
+) (notices []RestartNotice) {
+	for next.Kind() == reflect.Ptr {
+		if prev.IsNil() || next.IsNil() {
+			return nil
+		}
+		prev, next = prev.Elem(), next.Elem()
+	}
+	if next.Kind() != reflect.Struct {
+		return nil
+	}
+
+	tType := next.Type()
+	for i := 0; i < tType.NumField(); i++ {
+		fInfo := tType.Field(i)
+		if fInfo.PkgPath != "" {
+			continue
+		}
+		fTagName, fTagOpts, ok, err := l.parseFieldTag(fInfo)
+		if err != nil || !ok {
+			continue
+		}
+		pVal, nVal := prev.Field(i), next.Field(i)
+		fType := fInfo.Type
+		isStruct := (fType.Kind() == reflect.Struct ||
+			(fType.Kind() == reflect.Ptr && fType.Elem().Kind() == reflect.Struct)) &&
+			!(fTagName != "" && isOpaqueType(fType))
+
+		if fTagOpts.Static {
+			if !reflect.DeepEqual(pVal.Interface(), nVal.Interface()) {
+				nVal.Set(pVal)
+				lookupKey := l.fieldLookupKey(lookupPrefix, fTagName, fTagOpts)
+				if isStruct {
+					lookupKey = l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts) + "*"
+				}
+				notices = append(notices, RestartNotice{
+					Path:      fieldPath + "." + fInfo.Name,
+					LookupKey: lookupKey,
+				})
+			}
+			continue
+		}
+
+		if isStruct {
+			fieldPrefix := l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts)
+			if fType.Kind() == reflect.Struct {
+				notices = append(notices, l.keepStaticFields(fieldPrefix,
+					pVal, nVal, fieldPath+"."+fInfo.Name)...)
+				continue
+			}
+			if pVal.IsNil() && nVal.IsNil() {
+				continue
+			}
+			pElem, nElem := pVal, nVal
+			if pVal.IsNil() {
+				pElem = reflect.New(fType.Elem())
+			}
+			if nVal.IsNil() {
+				nElem = reflect.New(fType.Elem())
+			}
+			subNotices := l.keepStaticFields(fieldPrefix,
+				pElem, nElem, fieldPath+"."+fInfo.Name)
+			if len(subNotices) > 0 && nVal.IsNil() {
+				nVal.Set(nElem)
+			}
+			notices = append(notices, subNotices...)
+			continue
+		}
+
+		if fType.Kind() == reflect.Map && fTagOpts.Map &&
+			fType.Key().Kind() == reflect.String {
+			fmBasePrefix := l.fieldLookupPrefix(lookupPrefix, fTagName, fTagOpts)
+			for _, entryKey := range nVal.MapKeys() {
+				pEntry := pVal.MapIndex(entryKey)
+				if !pEntry.IsValid() {
+					continue
+				}
+				mapEntryKey := entryKey.Interface().(string)
+				nEntryVal := reflect.ValueOf(nVal.MapIndex(entryKey).Interface())
+				pEntryVal := reflect.ValueOf(pEntry.Interface())
+				if nEntryVal.Kind() != reflect.Ptr || pEntryVal.Kind() != reflect.Ptr ||
+					nEntryVal.Type() != pEntryVal.Type() {
+					continue
+				}
+				fmPrefix := fmBasePrefix + strings.ToUpper(mapEntryKey) + l.NamespaceSeparator
+				notices = append(notices, l.keepStaticFields(fmPrefix,
+					pEntryVal, nEntryVal,
+					fieldPath+"."+fInfo.Name+"["+mapEntryKey+"]")...)
+			}
+		}
+	}
+	return notices
+}
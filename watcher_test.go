@@ -0,0 +1,136 @@
+package stev_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rez-go/stev"
+)
+
+type WatchedConfig struct {
+	Name    string
+	Port    int32 `env:",static"`
+	Enabled bool
+}
+
+func writeEnvFile(t *testing.T, path, content string) {
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	os.Clearenv()
+	envPath := filepath.Join(t.TempDir(), "config.env")
+	writeEnvFile(t, envPath, "NAME=first\nPORT=8080\n")
+
+	w, err := stev.NewWatcher("", stev.WatcherOptions[WatchedConfig]{
+		Files:        []string{envPath},
+		IgnoreSIGHUP: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	assertStrEq(t, w.Current().Name, "first")
+
+	var changes []stev.Change[WatchedConfig]
+	w.Subscribe(func(c stev.Change[WatchedConfig]) {
+		changes = append(changes, c)
+	})
+
+	writeEnvFile(t, envPath, "NAME=second\nPORT=9090\nENABLED=true\n")
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("Expected 1 change, got %d", len(changes))
+	}
+	c := changes[0]
+	assertStrEq(t, c.Old.Name, "first")
+	assertStrEq(t, c.New.Name, "second")
+	if !c.New.Enabled {
+		t.Errorf("Unexpected value")
+	}
+	if c.New.Port != 8080 {
+		t.Errorf("Static field was hot-swapped: %d", c.New.Port)
+	}
+	if len(c.RestartRequired) != 1 || c.RestartRequired[0].LookupKey != "PORT" {
+		t.Errorf("Unexpected notices %#v", c.RestartRequired)
+	}
+	if w.Current() != c.New {
+		t.Errorf("New value was not published")
+	}
+}
+
+func TestWatcherSubscriberCallsBack(t *testing.T) {
+	os.Clearenv()
+	envPath := filepath.Join(t.TempDir(), "config.env")
+	writeEnvFile(t, envPath, "NAME=first\n")
+
+	w, err := stev.NewWatcher("", stev.WatcherOptions[WatchedConfig]{
+		Files:        []string{envPath},
+		IgnoreSIGHUP: true,
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	var names []string
+	w.Subscribe(func(c stev.Change[WatchedConfig]) {
+		names = append(names, w.Current().Name)
+		w.Subscribe(func(stev.Change[WatchedConfig]) {})
+		if err := w.Reload(); err != nil {
+			t.Errorf("Expected nil, got %#v", err)
+		}
+	})
+
+	writeEnvFile(t, envPath, "NAME=second\n")
+	done := make(chan error)
+	go func() { done <- w.Reload() }()
+	select {
+	case err = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Reload deadlocked")
+	}
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	if len(names) != 1 || names[0] != "second" {
+		t.Errorf("Unexpected notifications %v", names)
+	}
+}
+
+func TestWatcherKeepsCurrentOnFailure(t *testing.T) {
+	os.Clearenv()
+	envPath := filepath.Join(t.TempDir(), "config.env")
+	writeEnvFile(t, envPath, "NAME=first\n")
+
+	w, err := stev.NewWatcher("", stev.WatcherOptions[WatchedConfig]{
+		Files:        []string{envPath},
+		IgnoreSIGHUP: true,
+		Validate: func(cfg *WatchedConfig) error {
+			if cfg.Name == "" {
+				return errors.New("name is empty")
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected nil, got %#v", err)
+	}
+	prev := w.Current()
+
+	writeEnvFile(t, envPath, "PORT=abc\n")
+	if err := w.Reload(); err == nil {
+		t.Errorf("Expected error")
+	}
+	writeEnvFile(t, envPath, "NAME=\n")
+	if err := w.Reload(); err == nil {
+		t.Errorf("Expected error")
+	}
+	if w.Current() != prev {
+		t.Errorf("Invalid value was published")
+	}
+}